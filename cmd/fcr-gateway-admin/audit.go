@@ -0,0 +1,65 @@
+package main
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/audit"
+)
+
+const defaultAuditLog = "gateway-admin-audit.log"
+
+func runAudit(args []string) error {
+	if len(args) == 0 {
+		return errors.New("audit: expected list or verify")
+	}
+	fs := flag.NewFlagSet("audit "+args[0], flag.ContinueOnError)
+	file := fs.String("file", configString("AUDIT_LOG", defaultAuditLog), "audit log file")
+
+	switch args[0] {
+	case "list":
+		gateway := fs.String("gateway", "", "only show entries for this gateway node ID")
+		operation := fs.String("operation", "", "only show entries for this operation")
+		operator := fs.String("operator", "", "only show entries by this operator")
+		since := fs.String("since", "", "only show entries at or after this RFC3339 time")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		filter := audit.Filter{Gateway: *gateway, Operation: *operation, Operator: *operator}
+		if *since != "" {
+			t, err := time.Parse(time.RFC3339, *since)
+			if err != nil {
+				return fmt.Errorf("audit: invalid -since: %s", err)
+			}
+			filter.Since = t
+		}
+		entries, err := audit.Query(*file, filter)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "verify":
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if err := audit.Verify(*file); err != nil {
+			return err
+		}
+		fmt.Printf("Audit log %s verified\n", *file)
+		return nil
+	default:
+		return fmt.Errorf("audit: unknown sub-command %s", args[0])
+	}
+}
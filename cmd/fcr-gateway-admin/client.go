@@ -0,0 +1,79 @@
+package main
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcrgatewayadmin"
+)
+
+// newClient creates a gateway admin client configured from the environment. Messages are
+// signed by the signing daemon at GATEWAY_ADMIN_SIGNER if set, otherwise with
+// GATEWAY_ADMIN_PRIVATE_KEY.
+func newClient() (*fcrgatewayadmin.FilecoinRetrievalGatewayAdminClient, error) {
+	builder := clientSettings(configString("INVENTORY_PATH", defaultInventory), configString("KEY_PIN_PATH", defaultKeyPins))
+	if signerAddress := configString("GATEWAY_ADMIN_SIGNER", ""); signerAddress != "" {
+		s, err := fcrgatewayadmin.NewRemoteSigner(signerAddress)
+		if err != nil {
+			return nil, err
+		}
+		builder.SetSigner(s)
+	} else {
+		adminKey, keyVersion, err := adminKeyFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		builder.SetGatewayAdminPrivateKey(adminKey, keyVersion)
+	}
+	builder.SetRegisterURL(configString("REGISTER_API_URL", ""))
+	if proxyURL := configString("GATEWAY_ADMIN_PROXY", ""); proxyURL != "" {
+		d, err := fcrgatewayadmin.ParseProxyURL(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		builder.SetDialer(d)
+	}
+	return fcrgatewayadmin.NewFilecoinRetrievalGatewayAdminClient(*builder.Build())
+}
+
+// newLocalClient creates a gateway admin client for commands which only read or change the
+// inventory and key pins in the given files. Nothing is sent to gateways, so no admin key is
+// needed.
+func newLocalClient(inventoryPath string, keyPinPath string) (*fcrgatewayadmin.FilecoinRetrievalGatewayAdminClient, error) {
+	builder := clientSettings(inventoryPath, keyPinPath)
+	return fcrgatewayadmin.NewFilecoinRetrievalGatewayAdminClient(*builder.Build())
+}
+
+// clientSettings returns the settings common to all clients: logging, the inventory, the key
+// pins and the audit log.
+func clientSettings(inventoryPath string, keyPinPath string) fcrgatewayadmin.SettingsBuilder {
+	builder := fcrgatewayadmin.CreateSettings()
+	builder.SetLogging(configString("LOG_LEVEL", "warn"), configString("LOG_TARGET", "STDOUT"), "gateway-admin-cli")
+	builder.SetInventoryPath(inventoryPath)
+	builder.SetKeyPinPath(keyPinPath)
+	if auditLog := configString("AUDIT_LOG", ""); auditLog != "" {
+		builder.SetAuditLog(auditLog, configString("AUDIT_OPERATOR", ""))
+	}
+	return builder
+}
+
+// adminKeyFromEnv reads the admin private key and its version from the environment.
+func adminKeyFromEnv() (*fcrcrypto.KeyPair, *fcrcrypto.KeyVersion, error) {
+	encodedKey := configString("GATEWAY_ADMIN_PRIVATE_KEY", "")
+	if encodedKey == "" {
+		return nil, nil, errors.New("GATEWAY_ADMIN_PRIVATE_KEY is not set")
+	}
+	adminKey, err := fcrcrypto.DecodePrivateKey(encodedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyVersion, err := strconv.ParseUint(configString("GATEWAY_ADMIN_PRIVATE_KEY_VERSION", "1"), 10, 32)
+	if err != nil {
+		return nil, nil, err
+	}
+	return adminKey, fcrcrypto.DecodeKeyVersion(uint32(keyVersion)), nil
+}
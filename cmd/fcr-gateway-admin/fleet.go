@@ -0,0 +1,75 @@
+package main
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcrgatewayadmin"
+)
+
+const defaultFleetConfig = "fleet.yaml"
+
+func runFleet(args []string) error {
+	if len(args) == 0 {
+		return errors.New("fleet: expected plan or apply")
+	}
+	fs := flag.NewFlagSet("fleet "+args[0], flag.ContinueOnError)
+	file := fs.String("config", configString("FLEET_CONFIG", defaultFleetConfig), "desired state file")
+	dryRun := fs.Bool("dry-run", false, "show the changes apply would make without making them")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if args[0] != "plan" && args[0] != "apply" {
+		return fmt.Errorf("fleet: unknown sub-command %s", args[0])
+	}
+
+	desired, err := fcrgatewayadmin.LoadFleetConfig(*file)
+	if err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer client.Shutdown()
+
+	plan, err := client.PlanFleet(desired)
+	if err != nil {
+		return err
+	}
+	for _, w := range plan.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	if plan.Empty() {
+		fmt.Println("No changes: the fleet matches its configuration")
+		return nil
+	}
+	if args[0] == "plan" {
+		for _, action := range plan.Actions {
+			fmt.Printf("  %s\n", action)
+		}
+		fmt.Printf("%d changes planned\n", len(plan.Actions))
+		return nil
+	}
+
+	failures := 0
+	for _, result := range client.ApplyFleet(plan, *dryRun) {
+		switch {
+		case result.Err != nil:
+			failures++
+			fmt.Printf("  FAILED %s: %s\n", result.Action, result.Err)
+		case *dryRun:
+			fmt.Printf("  would %s\n", result.Action)
+		default:
+			fmt.Printf("  done %s\n", result.Action)
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d changes failed", failures, len(plan.Actions))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/selector"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcrgatewayadmin"
+)
+
+const defaultInventory = "gateway-admin-inventory.db"
+
+func runGateways(args []string) error {
+	if len(args) == 0 {
+		return errors.New("gateways: expected list, tag, untag, block, unblock or remove")
+	}
+	fs := flag.NewFlagSet("gateways "+args[0], flag.ContinueOnError)
+	file := fs.String("file", configString("INVENTORY_PATH", defaultInventory), "gateway inventory file")
+	sel := fs.String("selector", "", "select gateways by tag, for example region=eu,tier in (edge,core),!canary")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list", "tag", "untag", "block", "unblock", "remove":
+	default:
+		return fmt.Errorf("gateways: unknown sub-command %s", args[0])
+	}
+	client, err := newLocalClient(*file, configString("KEY_PIN_PATH", defaultKeyPins))
+	if err != nil {
+		return err
+	}
+	defer client.Shutdown()
+
+	switch args[0] {
+	case "list":
+		gateways, err := client.SelectGateways(*sel)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, gw := range gateways {
+			if err := enc.Encode(gw); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "tag", "untag":
+		if fs.NArg() < 2 {
+			return fmt.Errorf("gateways %s: expected a gateway node ID and at least one tag", args[0])
+		}
+		if args[0] == "untag" {
+			return client.RemoveGatewayTags(fs.Arg(0), fs.Args()[1:])
+		}
+		tags, err := selector.ParseTags(fs.Args()[1:])
+		if err != nil {
+			return err
+		}
+		return client.SetGatewayTags(fs.Arg(0), tags)
+	default:
+		nodeIDs, err := targetNodeIDs(client, *sel, fs.Args())
+		if err != nil {
+			return err
+		}
+		for _, nodeID := range nodeIDs {
+			switch args[0] {
+			case "block":
+				err = client.BlockGateway(nodeID)
+			case "unblock":
+				err = client.UnblockGateway(nodeID)
+			case "remove":
+				err = client.RemoveGateway(nodeID)
+			}
+			if err != nil {
+				return fmt.Errorf("gateways %s %s: %s", args[0], nodeID, err)
+			}
+			fmt.Printf("%s %s\n", args[0], nodeID)
+		}
+		return nil
+	}
+}
+
+// targetNodeIDs returns the node IDs named on the command line, or those matching the selector.
+// Exactly one of the two must be given.
+func targetNodeIDs(client *fcrgatewayadmin.FilecoinRetrievalGatewayAdminClient, sel string, args []string) ([]string, error) {
+	if (sel == "") == (len(args) == 0) {
+		return nil, errors.New("expected either gateway node IDs or -selector")
+	}
+	if sel == "" {
+		return args, nil
+	}
+	gateways, err := client.SelectGateways(sel)
+	if err != nil {
+		return nil, err
+	}
+	nodeIDs := make([]string, 0, len(gateways))
+	for _, gw := range gateways {
+		nodeIDs = append(nodeIDs, gw.NodeID())
+	}
+	return nodeIDs, nil
+}
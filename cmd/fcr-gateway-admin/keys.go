@@ -0,0 +1,72 @@
+package main
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const defaultKeyPins = "gateway-admin-keys.db"
+
+func runKeys(args []string) error {
+	if len(args) == 0 {
+		return errors.New("keys: expected list, approve or revoke")
+	}
+	fs := flag.NewFlagSet("keys "+args[0], flag.ContinueOnError)
+	file := fs.String("file", configString("KEY_PIN_PATH", defaultKeyPins), "gateway key pin file")
+	pending := fs.Bool("pending", false, "only list gateways with a pending key change")
+	keepOld := fs.Bool("keep-old", false, "keep trusting a gateway's other known keys when approving its new key")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list", "approve", "revoke":
+	default:
+		return fmt.Errorf("keys: unknown sub-command %s", args[0])
+	}
+	client, err := newLocalClient(configString("INVENTORY_PATH", defaultInventory), *file)
+	if err != nil {
+		return err
+	}
+	defer client.Shutdown()
+
+	switch args[0] {
+	case "list":
+		pins, err := client.ListKeyPins()
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, p := range pins {
+			if *pending && p.PendingKey == "" {
+				continue
+			}
+			if err := enc.Encode(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		if fs.NArg() == 0 {
+			return fmt.Errorf("keys %s: expected gateway node IDs", args[0])
+		}
+		for _, nodeID := range fs.Args() {
+			var err error
+			if args[0] == "approve" {
+				err = client.ApproveGatewayKey(nodeID, *keepOld)
+			} else {
+				err = client.RevokeGatewayKey(nodeID)
+			}
+			if err != nil {
+				return fmt.Errorf("keys %s %s: %s", args[0], nodeID, err)
+			}
+			fmt.Printf("%s %s\n", args[0], nodeID)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,78 @@
+// Command fcr-gateway-admin is the command line wrapper around the gateway admin library.
+package main
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/config"
+)
+
+// command is a single CLI sub-command.
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"audit":      {"audit list|verify [flags]", runAudit},
+	"fleet":      {"fleet plan|apply [-config file] [-dry-run]", runFleet},
+	"gateways":   {"gateways list|tag|untag|block|unblock|remove [flags] [node-id...] [key=value...]", runGateways},
+	"keys":       {"keys list|approve|revoke [-file file] [-pending] [-keep-old] [node-id...]", runKeys},
+	"offers":     {"offers evict|pin|unpin|refresh|export|verify|consistency [-provider node-id] [-roots r1,r2] [-gateway node-id|-selector selector] [-file file] [-resume token]", runOffers},
+	"reputation": {"reputation get|list|export|import [-gateway node-id|-selector selector] [flags]", runReputation},
+	"signer":     {"signer serve [-socket file]", runSigner},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [arguments]\n\nCommands:\n", os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+}
+
+// configString returns a value from the environment, or def if not set.
+func configString(key string, def string) string {
+	conf := config.NewConfig()
+	if conf.IsSet(key) {
+		return conf.GetString(key)
+	}
+	return def
+}
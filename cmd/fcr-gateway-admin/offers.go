@@ -0,0 +1,222 @@
+package main
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/offerexport"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcrgatewayadmin"
+)
+
+func runOffers(args []string) error {
+	if len(args) == 0 {
+		return errors.New("offers: expected evict, pin, unpin, refresh, export, verify or consistency")
+	}
+	fs := flag.NewFlagSet("offers "+args[0], flag.ContinueOnError)
+	gateway := fs.String("gateway", "", "node ID of the managed gateway")
+	sel := fs.String("selector", "", "apply to every managed gateway matching this selector")
+	provider := fs.String("provider", "", "provider node ID")
+	roots := fs.String("roots", "", "comma separated merkle roots of the offers; all of the provider's offers if not set")
+	file := fs.String("file", "", "export file, .jsonl, .csv or .bin")
+	format := fs.String("format", "", "export format, if not given by the file extension")
+	resume := fs.String("resume", "", "resume token of an interrupted export; the export is appended to -file")
+	all := fs.Bool("all", false, "verify: report valid offers as well as failures")
+	sample := fs.Float64("sample", 1, "consistency: fraction of offers to compare")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	switch args[0] {
+	case "export":
+		return exportOffers(*gateway, *file, *format, *resume)
+	case "verify":
+		return verifyOffers(*gateway, *file, *format, *all)
+	case "consistency":
+		return checkOfferConsistency(*sel, *sample)
+	}
+	if *provider == "" {
+		return fmt.Errorf("offers %s: -provider is required", args[0])
+	}
+	providerID, err := nodeid.NewNodeIDFromString(*provider)
+	if err != nil {
+		return err
+	}
+	var merkleRoots []string
+	if *roots != "" {
+		merkleRoots = strings.Split(*roots, ",")
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer client.Shutdown()
+	targets, err := selectTargets(client, *gateway, *sel)
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	enc := json.NewEncoder(os.Stdout)
+	for _, gw := range targets {
+		var ack *fcrgatewayadmin.OfferAck
+		switch args[0] {
+		case "evict":
+			ack, err = client.EvictOffers(&gw.Register, providerID, merkleRoots...)
+		case "pin":
+			ack, err = client.PinOffers(&gw.Register, providerID, merkleRoots...)
+		case "unpin":
+			ack, err = client.UnpinOffers(&gw.Register, providerID, merkleRoots...)
+		case "refresh":
+			ack, err = client.RefreshOffers(&gw.Register, providerID)
+		default:
+			return fmt.Errorf("offers: unknown sub-command %s", args[0])
+		}
+		if err != nil {
+			failures++
+			fmt.Fprintf(os.Stderr, "%s: %s\n", gw.NodeID(), err)
+			continue
+		}
+		if err = enc.Encode(ack); err != nil {
+			return err
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d gateways failed", failures, len(targets))
+	}
+	return nil
+}
+
+func exportOffers(gateway string, file string, format string, resume string) error {
+	if gateway == "" || file == "" {
+		return errors.New("offers export: -gateway and -file are required")
+	}
+	if format == "" {
+		f, err := offerexport.FormatFromPath(file)
+		if err != nil {
+			return err
+		}
+		format = string(f)
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer client.Shutdown()
+	gw, err := client.GetGateway(gateway)
+	if err != nil {
+		return err
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume != "" {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	out, err := os.OpenFile(file, flags, 0644)
+	if err != nil {
+		return err
+	}
+	count, token, err := client.ExportOffers(&gw.Register, out, format, resume)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if token != "" {
+			fmt.Fprintf(os.Stderr, "Exported %d offers before failing. Resume with: -resume %s\n", count, token)
+		}
+		return err
+	}
+	fmt.Printf("Exported %d offers from %s to %s\n", count, gateway, file)
+	return nil
+}
+
+// verifyOffers verifies the offers served by a managed gateway, or those in an export file.
+func verifyOffers(gateway string, file string, format string, all bool) error {
+	if (gateway == "") == (file == "") {
+		return errors.New("offers verify: expected either -gateway or -file")
+	}
+	checked, failed := 0, 0
+	enc := json.NewEncoder(os.Stdout)
+	report := func(result fcrgatewayadmin.OfferVerification) error {
+		checked++
+		if !result.Valid() {
+			failed++
+		} else if !all {
+			return nil
+		}
+		return enc.Encode(result)
+	}
+
+	var err error
+	if file != "" {
+		err = verifyOfferFile(file, format, report)
+	} else {
+		client, cerr := newClient()
+		if cerr != nil {
+			return cerr
+		}
+		defer client.Shutdown()
+		gw, gerr := client.GetGateway(gateway)
+		if gerr != nil {
+			return gerr
+		}
+		err = client.VerifyOffers(&gw.Register, report)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Verified %d offers: %d failed\n", checked, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d offers failed verification", failed)
+	}
+	return nil
+}
+
+func verifyOfferFile(file string, format string, report func(fcrgatewayadmin.OfferVerification) error) error {
+	if format == "" {
+		f, err := offerexport.FormatFromPath(file)
+		if err != nil {
+			return err
+		}
+		format = string(f)
+	}
+	verifier, err := fcrgatewayadmin.NewRegisterOfferVerifier(configString("REGISTER_API_URL", ""))
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return fcrgatewayadmin.VerifyOfferExport(in, format, verifier, report)
+}
+
+// checkOfferConsistency compares the offers of the managed gateways matching the selector.
+func checkOfferConsistency(sel string, sample float64) error {
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer client.Shutdown()
+	report, err := client.CheckOfferConsistency(sel, sample)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(report); err != nil {
+		return err
+	}
+	if !report.Consistent() {
+		return errors.New("gateways are not consistent")
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package main
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcrgatewayadmin"
+)
+
+func runReputation(args []string) error {
+	if len(args) == 0 {
+		return errors.New("reputation: expected get, list, export or import")
+	}
+	fs := flag.NewFlagSet("reputation "+args[0], flag.ContinueOnError)
+	gateway := fs.String("gateway", "", "node ID of the managed gateway")
+	sel := fs.String("selector", "", "import onto every managed gateway matching this selector")
+	file := fs.String("file", "", "reputation file, .csv or .json")
+	dryRun := fs.Bool("dry-run", false, "validate the file without changing any reputations")
+	client := fs.String("client", "", "client node ID")
+	limit := fs.Int("limit", 100, "maximum number of reputations to list")
+	cursor := fs.String("cursor", "", "continue listing from this cursor")
+	min := fs.String("min", "", "only list reputations of at least this value")
+	max := fs.String("max", "", "only list reputations of at most this value")
+	since := fs.String("since", "", "only list reputations changed at or after this RFC3339 time")
+	history := fs.Bool("history", false, "include reputation history where the gateway records it")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if (args[0] == "export" || args[0] == "import") && *file == "" {
+		return fmt.Errorf("reputation %s: -file is required", args[0])
+	}
+
+	adminClient, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer adminClient.Shutdown()
+
+	switch args[0] {
+	case "get":
+		if *gateway == "" || *client == "" {
+			return errors.New("reputation get: -gateway and -client are required")
+		}
+		clientID, err := nodeid.NewNodeIDFromString(*client)
+		if err != nil {
+			return err
+		}
+		gw, err := adminClient.GetGateway(*gateway)
+		if err != nil {
+			return err
+		}
+		rep, err := adminClient.GetClientReputation(&gw.Register, clientID)
+		if err != nil {
+			return err
+		}
+		if rep == nil {
+			return fmt.Errorf("Gateway %s holds no reputation for client %s", *gateway, *client)
+		}
+		return json.NewEncoder(os.Stdout).Encode(rep)
+	case "list":
+		if *gateway == "" {
+			return errors.New("reputation list: -gateway is required")
+		}
+		filter := fcrgatewayadmin.ReputationFilter{Cursor: *cursor, Limit: int32(*limit), IncludeHistory: *history}
+		if filter.MinReputation, err = parseOptionalInt(*min); err != nil {
+			return fmt.Errorf("reputation list: invalid -min: %s", err)
+		}
+		if filter.MaxReputation, err = parseOptionalInt(*max); err != nil {
+			return fmt.Errorf("reputation list: invalid -max: %s", err)
+		}
+		if *since != "" {
+			t, err := time.Parse(time.RFC3339, *since)
+			if err != nil {
+				return fmt.Errorf("reputation list: invalid -since: %s", err)
+			}
+			filter.ChangedSince = t.Unix()
+		}
+		gw, err := adminClient.GetGateway(*gateway)
+		if err != nil {
+			return err
+		}
+		page, err := adminClient.ListClientReputations(&gw.Register, &filter)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, rep := range page.Reputations {
+			if err := enc.Encode(rep); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor != "" {
+			fmt.Fprintf(os.Stderr, "More reputations available: -cursor %s\n", page.NextCursor)
+		}
+		return nil
+	case "export":
+		if *gateway == "" {
+			return errors.New("reputation export: -gateway is required")
+		}
+		gw, err := adminClient.GetGateway(*gateway)
+		if err != nil {
+			return err
+		}
+		count, err := adminClient.ExportClientReputations(&gw.Register, *file)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d reputations from %s to %s\n", count, *gateway, *file)
+		return nil
+	case "import":
+		targets, err := selectTargets(adminClient, *gateway, *sel)
+		if err != nil {
+			return err
+		}
+		failures := 0
+		for _, gw := range targets {
+			rows, err := adminClient.ImportClientReputations(&gw.Register, *file, *dryRun)
+			if err != nil {
+				return err
+			}
+			imported := 0
+			for _, row := range rows {
+				if row.Err != nil {
+					failures++
+					fmt.Printf("%s: line %d: %s\n", gw.NodeID(), row.Line, row.Err)
+					continue
+				}
+				imported++
+			}
+			verb := "Imported"
+			if *dryRun {
+				verb = "Validated"
+			}
+			fmt.Printf("%s: %s %d of %d reputations\n", gw.NodeID(), verb, imported, len(rows))
+		}
+		if failures > 0 {
+			return fmt.Errorf("%d rows failed", failures)
+		}
+		return nil
+	default:
+		return fmt.Errorf("reputation: unknown sub-command %s", args[0])
+	}
+}
+
+// selectTargets returns the managed gateway named by nodeID, or those matching the selector.
+func selectTargets(client *fcrgatewayadmin.FilecoinRetrievalGatewayAdminClient, nodeID string, sel string) ([]fcrgatewayadmin.ManagedGateway, error) {
+	if (nodeID == "") == (sel == "") {
+		return nil, errors.New("expected either -gateway or -selector")
+	}
+	if sel != "" {
+		return client.SelectGateways(sel)
+	}
+	gw, err := client.GetGateway(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return []fcrgatewayadmin.ManagedGateway{*gw}, nil
+}
+
+// parseOptionalInt returns nil for an empty string.
+func parseOptionalInt(s string) (*int64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
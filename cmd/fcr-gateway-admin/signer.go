@@ -0,0 +1,66 @@
+package main
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcrgatewayadmin"
+)
+
+const defaultSignerSocket = "gateway-admin-signer.sock"
+
+// runSigner runs a signing daemon holding the admin private key, so other invocations can
+// sign with GATEWAY_ADMIN_SIGNER set instead of holding the key themselves. Each signing
+// request is recorded in AUDIT_LOG if set.
+func runSigner(args []string) error {
+	if len(args) == 0 || args[0] != "serve" {
+		return errors.New("signer: expected serve")
+	}
+	fs := flag.NewFlagSet("signer serve", flag.ContinueOnError)
+	socket := fs.String("socket", defaultSignerSocket, "Unix socket to listen on")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	adminKey, keyVersion, err := adminKeyFromEnv()
+	if err != nil {
+		return err
+	}
+
+	// Remove a socket left behind by a previous daemon.
+	if err := os.Remove(*socket); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// Only the owner of the daemon may ask it to sign. The socket is created with owner only
+	// permissions, rather than restricted after it is created, so it is never open to others.
+	restore := privateUmask()
+	l, err := net.Listen("unix", *socket)
+	restore()
+	if err != nil {
+		return err
+	}
+
+	stop := make(chan os.Signal, 1)
+	stopped := make(chan struct{})
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		close(stopped)
+		l.Close()
+	}()
+	fmt.Printf("Signing admin messages on %s\n", *socket)
+	err = fcrgatewayadmin.ServeSigner(l, fcrgatewayadmin.NewLocalSigner(adminKey, keyVersion),
+		configString("AUDIT_LOG", ""), configString("AUDIT_OPERATOR", ""))
+	select {
+	case <-stopped:
+		return nil
+	default:
+		return err
+	}
+}
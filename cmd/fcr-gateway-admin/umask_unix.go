@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"syscall"
+)
+
+// privateUmask makes files created until restore is called accessible only to their owner.
+func privateUmask() (restore func()) {
+	old := syscall.Umask(0077)
+	return func() { syscall.Umask(old) }
+}
@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package main
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// privateUmask is a no-op on Windows, which has no umask. Unix sockets there are protected
+// by the ACL of the directory they are created in.
+func privateUmask() (restore func()) {
+	return func() {}
+}
@@ -7,5 +7,13 @@ require (
 	github.com/ConsenSys/fc-retrieval-register v0.0.0-20210305042819-da4613bcbb05
 	github.com/bitly/go-simplejson v0.5.0
 	github.com/bmizerany/assert v0.0.0-20160611221934-b7ed37b82869 // indirect
+	github.com/prometheus/client_golang v1.9.0
 	github.com/spf13/viper v1.7.1
+	go.etcd.io/bbolt v1.3.5
+	go.opentelemetry.io/otel v0.20.0
+	go.opentelemetry.io/otel/exporters/stdout v0.20.0
+	go.opentelemetry.io/otel/sdk v0.20.0
+	go.opentelemetry.io/otel/trace v0.20.0
+	golang.org/x/crypto v0.0.0-20210220033148-5ea612d1eb83
+	gopkg.in/yaml.v2 v2.4.0
 )
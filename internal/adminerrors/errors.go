@@ -0,0 +1,159 @@
+package adminerrors
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+// Sentinel errors. Errors returned by admin operations match these with errors.Is.
+var (
+	ErrGatewayUnreachable = errors.New("Gateway unreachable")
+	ErrSignatureInvalid   = errors.New("Signature invalid")
+	ErrKeyRejected        = errors.New("Key rejected")
+	ErrUnexpectedMessage  = errors.New("Unexpected message")
+	ErrProtocolMismatch   = errors.New("Protocol mismatch")
+	ErrGatewayBlocked     = errors.New("Gateway blocked")
+	ErrRegisterFailure    = errors.New("Register failure")
+	ErrKeyChanged         = errors.New("Gateway key changed unexpectedly")
+)
+
+// Error is an admin operation error of one of the sentinel kinds, with the gateway it
+// concerns and the underlying cause, if any.
+type Error struct {
+	Kind    error
+	Gateway string
+	Err     error
+}
+
+// New creates an error of a sentinel kind.
+func New(kind error, gateway string, cause error) *Error {
+	return &Error{Kind: kind, Gateway: gateway, Err: cause}
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.Error()
+	if e.Gateway != "" {
+		msg += " (gateway " + e.Gateway + ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Is matches the sentinel kind of the error.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the underlying cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KeyRejectionReason is the reason code a gateway gives for rejecting a private key.
+type KeyRejectionReason = fcradminmessages.KeyRejectionReason
+
+// Key rejection reasons.
+const (
+	ReasonUnspecified        = fcradminmessages.KeyRejectionUnspecified
+	ReasonAlreadyInitialized = fcradminmessages.KeyRejectionAlreadyInitialized
+	ReasonBadKeyVersion      = fcradminmessages.KeyRejectionBadKeyVersion
+	ReasonDecodeFailure      = fcradminmessages.KeyRejectionDecodeFailure
+	ReasonUnauthorizedAdmin  = fcradminmessages.KeyRejectionUnauthorizedAdmin
+)
+
+// KeyRejectedError is returned when a gateway does not accept a private key. It matches ErrKeyRejected.
+// Message is the gateway's description of the problem, if it sent one.
+type KeyRejectedError struct {
+	Gateway string
+	Reason  KeyRejectionReason
+	Message string
+}
+
+func (e *KeyRejectedError) Error() string {
+	msg := fmt.Sprintf("Key not accepted by gateway %s: %s", e.Gateway, e.Reason)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Is matches ErrKeyRejected.
+func (e *KeyRejectedError) Is(target error) bool {
+	return target == ErrKeyRejected
+}
+
+// UnexpectedMessageError is returned when a gateway responds with a message of the wrong
+// type. It matches ErrUnexpectedMessage.
+type UnexpectedMessageError struct {
+	Gateway     string
+	Operation   string
+	MessageType int32
+}
+
+func (e *UnexpectedMessageError) Error() string {
+	return fmt.Sprintf("Unexpected message in response to %s message from gateway %s: %d", e.Operation, e.Gateway, e.MessageType)
+}
+
+// Is matches ErrUnexpectedMessage.
+func (e *UnexpectedMessageError) Is(target error) bool {
+	return target == ErrUnexpectedMessage
+}
+
+// KeyChangedError is returned when a gateway signs a response with a key which conflicts
+// with its known keys: a different key for a known key version, or a key version older
+// than the latest known one. This could mean the gateway is being impersonated. It
+// matches ErrKeyChanged.
+type KeyChangedError struct {
+	Gateway       string
+	KeyVersion    uint32
+	LatestVersion uint32
+}
+
+func (e *KeyChangedError) Error() string {
+	if e.KeyVersion < e.LatestVersion {
+		return fmt.Sprintf("Gateway %s signed with key version %d, older than its latest known key version %d: the gateway may be impersonated", e.Gateway, e.KeyVersion, e.LatestVersion)
+	}
+	return fmt.Sprintf("Gateway %s signed with a different key for known key version %d: the gateway may be impersonated", e.Gateway, e.KeyVersion)
+}
+
+// Is matches ErrKeyChanged.
+func (e *KeyChangedError) Is(target error) bool {
+	return target == ErrKeyChanged
+}
+
+// UnapprovedKeyError is returned when a gateway signs a response with a key other than its
+// pinned key. The key is held as pending until an operator approves or revokes it. It
+// matches ErrKeyChanged.
+type UnapprovedKeyError struct {
+	Gateway       string
+	KeyVersion    uint32
+	PinnedVersion uint32
+}
+
+func (e *UnapprovedKeyError) Error() string {
+	return fmt.Sprintf("Gateway %s signed with key version %d, which is not its pinned key (key version %d): the gateway may be impersonated, approve the key change if it is expected", e.Gateway, e.KeyVersion, e.PinnedVersion)
+}
+
+// Is matches ErrKeyChanged.
+func (e *UnapprovedKeyError) Is(target error) bool {
+	return target == ErrKeyChanged
+}
@@ -0,0 +1,307 @@
+package audit
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// OutcomeSuccess is recorded when an operation completed without error.
+	OutcomeSuccess = "success"
+	// OutcomeFailure is recorded when an operation returned an error.
+	OutcomeFailure = "failure"
+
+	redactedValue = "[REDACTED]"
+)
+
+// Parameter names containing any of these fragments are never written to the audit log.
+var secretParamFragments = []string{"private", "secret", "signature", "password"}
+
+// Entry is a single record in the audit log.
+type Entry struct {
+	Sequence  uint64            `json:"seq"`
+	Time      time.Time         `json:"time"`
+	Operator  string            `json:"operator"`
+	Gateway   string            `json:"gateway"`
+	Operation string            `json:"operation"`
+	Params    map[string]string `json:"params,omitempty"`
+	Outcome   string            `json:"outcome"`
+	Error     string            `json:"error,omitempty"`
+	PrevHash  string            `json:"prev_hash"`
+	Hash      string            `json:"hash"`
+}
+
+// Filter selects audit log entries. Empty fields match everything.
+type Filter struct {
+	Gateway   string
+	Operation string
+	Operator  string
+	Since     time.Time
+	Until     time.Time
+}
+
+// Log is an append-only, hash-chained audit log stored as JSON lines. Several processes
+// may append to the same log: each append holds an exclusive lock on the file while it
+// reads the entries written since its last append and writes its own.
+type Log struct {
+	path     string
+	operator string
+	lock     sync.Mutex
+	// offset is the length of the file up to the last entry read or written.
+	offset   int64
+	lastSeq  uint64
+	lastHash string
+}
+
+// Open opens, or creates, the audit log at path. The chain is verified as entries are
+// appended, so a broken chain is reported by Record rather than preventing the log
+// being opened.
+func Open(path string, operator string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return &Log{path: path, operator: operator}, nil
+}
+
+// Record appends an entry for an operation on a gateway. A nil opErr records success.
+// Nothing is appended, and an error is returned, if the existing chain is broken.
+func (l *Log) Record(gateway string, operation string, params map[string]string, opErr error) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("Unable to lock audit log %s: %s", l.path, err)
+	}
+	defer unlockFile(f)
+	if err := l.readNew(f); err != nil {
+		return err
+	}
+
+	e := Entry{
+		Sequence:  l.lastSeq + 1,
+		Time:      time.Now().UTC(),
+		Operator:  l.operator,
+		Gateway:   gateway,
+		Operation: operation,
+		Params:    redactParams(params),
+		Outcome:   OutcomeSuccess,
+		PrevHash:  l.lastHash,
+	}
+	if opErr != nil {
+		e.Outcome = OutcomeFailure
+		e.Error = opErr.Error()
+	}
+	hash, err := hashEntry(e)
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if _, err = f.Write(line); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+
+	l.offset += int64(len(line))
+	l.lastSeq = e.Sequence
+	l.lastHash = e.Hash
+	return nil
+}
+
+// readNew reads the entries appended to the log since the last entry this Log read or
+// wrote, including those written by other processes, and checks they continue the chain.
+func (l *Log) readNew(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < l.offset {
+		return fmt.Errorf("Audit log %s has been truncated", l.path)
+	}
+	if info.Size() == l.offset {
+		return nil
+	}
+	if _, err := f.Seek(l.offset, io.SeekStart); err != nil {
+		return err
+	}
+	entries, err := readEntries(f)
+	if err != nil {
+		return err
+	}
+	if err := continueChain(entries, l.lastSeq, l.lastHash); err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		l.lastSeq = last.Sequence
+		l.lastHash = last.Hash
+	}
+	l.offset = info.Size()
+	return nil
+}
+
+// ReadAll returns every entry in the audit log at path.
+func ReadAll(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readEntries(f)
+}
+
+func readEntries(r io.Reader) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("Corrupt audit log entry after sequence %d: %s", len(entries), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Query returns the entries in the audit log at path which match the filter.
+func Query(path string, filter Filter) ([]Entry, error) {
+	entries, err := ReadAll(path)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]Entry, 0)
+	for _, e := range entries {
+		if filter.matches(e) {
+			matched = append(matched, e)
+		}
+	}
+	return matched, nil
+}
+
+// Verify checks the hash chain of the audit log at path, returning an error
+// describing the first entry which has been tampered with.
+func Verify(path string) error {
+	entries, err := ReadAll(path)
+	if err != nil {
+		return err
+	}
+	return verifyChain(entries)
+}
+
+func (f Filter) matches(e Entry) bool {
+	if f.Gateway != "" && !strings.EqualFold(f.Gateway, e.Gateway) {
+		return false
+	}
+	if f.Operation != "" && f.Operation != e.Operation {
+		return false
+	}
+	if f.Operator != "" && f.Operator != e.Operator {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+func verifyChain(entries []Entry) error {
+	return continueChain(entries, 0, "")
+}
+
+// continueChain checks that entries continue a chain whose last entry has the given
+// sequence and hash.
+func continueChain(entries []Entry, lastSeq uint64, lastHash string) error {
+	prevHash := lastHash
+	for i, e := range entries {
+		if e.Sequence != lastSeq+uint64(i+1) {
+			return fmt.Errorf("Audit log sequence broken at entry %d: found sequence %d", lastSeq+uint64(i+1), e.Sequence)
+		}
+		if e.PrevHash != prevHash {
+			return fmt.Errorf("Audit log chain broken at sequence %d", e.Sequence)
+		}
+		hash, err := hashEntry(e)
+		if err != nil {
+			return err
+		}
+		if hash != e.Hash {
+			return fmt.Errorf("Audit log entry %d has been modified", e.Sequence)
+		}
+		prevHash = e.Hash
+	}
+	return nil
+}
+
+// hashEntry hashes everything in the entry apart from the hash itself.
+func hashEntry(e Entry) (string, error) {
+	e.Hash = ""
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func redactParams(params map[string]string) map[string]string {
+	if len(params) == 0 {
+		return nil
+	}
+	redacted := make(map[string]string, len(params))
+	for k, v := range params {
+		redacted[k] = v
+		lower := strings.ToLower(k)
+		for _, frag := range secretParamFragments {
+			if strings.Contains(lower, frag) {
+				redacted[k] = redactedValue
+				break
+			}
+		}
+	}
+	return redacted
+}
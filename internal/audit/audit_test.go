@@ -0,0 +1,181 @@
+package audit
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newLog returns the path of an audit log holding three entries.
+func newLog(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []struct {
+		gateway   string
+		operation string
+		params    map[string]string
+		err       error
+	}{
+		{"gw1", "initialize", map[string]string{"key_version": "1", "private_key": "secret"}, nil},
+		{"gw1", "set-client-reputation", map[string]string{"client_id": "c1"}, errors.New("Gateway unreachable")},
+		{"gw2", "block", nil, nil},
+	}
+	for _, r := range records {
+		if err := l.Record(r.gateway, r.operation, r.params, r.err); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+// rewrite replaces the entries of the audit log at path with those returned by change.
+func rewrite(t *testing.T, path string, change func(entries []Entry) []Entry) {
+	t.Helper()
+	entries, err := ReadAll(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for _, e := range change(entries) {
+		line, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	if err := ioutil.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	entries, err := ReadAll(newLog(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("audit log has %d entries, want 3", len(entries))
+	}
+	if got := entries[0].Params["private_key"]; got != redactedValue {
+		t.Errorf("private key parameter recorded as %q, want it redacted", got)
+	}
+	if got := entries[0].Params["key_version"]; got != "1" {
+		t.Errorf("key version parameter recorded as %q, want 1", got)
+	}
+	if entries[1].Outcome != OutcomeFailure || entries[1].Error != "Gateway unreachable" {
+		t.Errorf("failed operation recorded as %+v", entries[1])
+	}
+	if entries[2].Outcome != OutcomeSuccess || entries[2].Operator != "operator" {
+		t.Errorf("successful operation recorded as %+v", entries[2])
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(entries []Entry) []Entry
+		wantErr string
+	}{
+		{"unchanged", func(entries []Entry) []Entry { return entries }, ""},
+		{"field modified", func(entries []Entry) []Entry {
+			entries[1].Outcome = OutcomeSuccess
+			return entries
+		}, "entry 2 has been modified"},
+		{"entry removed", func(entries []Entry) []Entry {
+			return append(entries[:1], entries[2:]...)
+		}, "sequence broken"},
+		{"last entry removed", func(entries []Entry) []Entry { return entries[:2] }, ""},
+		{"entries reordered", func(entries []Entry) []Entry {
+			entries[1], entries[2] = entries[2], entries[1]
+			return entries
+		}, "sequence broken"},
+		{"entry rehashed", func(entries []Entry) []Entry {
+			entries[1].Gateway = "gw3"
+			entries[1].Hash, _ = hashEntry(entries[1])
+			return entries
+		}, "chain broken at sequence 3"},
+		{"entry replaced with a new chain", func(entries []Entry) []Entry {
+			entries[0].Operation = "rekey"
+			entries[0].Hash, _ = hashEntry(entries[0])
+			return entries
+		}, "chain broken at sequence 2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := newLog(t)
+			rewrite(t, path, test.change)
+			err := Verify(path)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("Verify returned %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("Verify returned %v, want an error containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecordRefusesBrokenChain(t *testing.T) {
+	path := newLog(t)
+	l, err := Open(path, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrite(t, path, func(entries []Entry) []Entry {
+		entries[2].Gateway = "gw3"
+		return entries
+	})
+	if err := l.Record("gw1", "block", nil, nil); err == nil {
+		t.Fatal("appended to an audit log whose chain is broken")
+	}
+	if entries, _ := ReadAll(path); len(entries) != 3 {
+		t.Errorf("audit log has %d entries, want 3", len(entries))
+	}
+}
+
+func TestQuery(t *testing.T) {
+	path := newLog(t)
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []uint64
+	}{
+		{"everything", Filter{}, []uint64{1, 2, 3}},
+		{"gateway", Filter{Gateway: "GW1"}, []uint64{1, 2}},
+		{"operation", Filter{Operation: "block"}, []uint64{3}},
+		{"operator", Filter{Operator: "someone else"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := Query(path, test.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]uint64, 0, len(entries))
+			for _, e := range entries {
+				got = append(got, e.Sequence)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("query returned entries %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("query returned entries %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package audit
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on the file, waiting for any other process holding it.
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package audit
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"os"
+)
+
+// lockFile is a no-op on Windows, where only one process may append to an audit log.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,261 @@
+package consistency
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+// MissingOffer is an offer held by some gateways but not others.
+type MissingOffer struct {
+	ProviderID string   `json:"provider_id"`
+	MerkleRoot string   `json:"merkle_root"`
+	PresentOn  []string `json:"present_on"`
+	MissingOn  []string `json:"missing_on"`
+}
+
+// PriceDiscrepancy is a CID offered by the same provider at different prices on different gateways.
+type PriceDiscrepancy struct {
+	ProviderID string            `json:"provider_id"`
+	CID        string            `json:"cid"`
+	Prices     map[string]uint64 `json:"prices"`
+}
+
+// StaleExpiry is an offer held with an older expiry on some gateways than on others.
+type StaleExpiry struct {
+	ProviderID string           `json:"provider_id"`
+	MerkleRoot string           `json:"merkle_root"`
+	Expiries   map[string]int64 `json:"expiries"`
+	Latest     int64            `json:"latest"`
+}
+
+// ExpiredOffer is an offer a gateway still holds after it has expired.
+type ExpiredOffer struct {
+	Gateway    string `json:"gateway"`
+	ProviderID string `json:"provider_id"`
+	MerkleRoot string `json:"merkle_root"`
+	Expiry     int64  `json:"expiry"`
+}
+
+// Report is the result of comparing the offers of several gateways.
+type Report struct {
+	GeneratedAt        time.Time          `json:"generated_at"`
+	SampleRate         float64            `json:"sample_rate"`
+	Gateways           []string           `json:"gateways"`
+	OfferCounts        map[string]int     `json:"offer_counts"`
+	Errors             map[string]string  `json:"errors,omitempty"`
+	Missing            []MissingOffer     `json:"missing"`
+	PriceDiscrepancies []PriceDiscrepancy `json:"price_discrepancies"`
+	StaleExpiries      []StaleExpiry      `json:"stale_expiries"`
+	Expired            []ExpiredOffer     `json:"expired"`
+}
+
+// Consistent returns true if no differences were found and every gateway was read.
+func (r *Report) Consistent() bool {
+	return len(r.Errors) == 0 && len(r.Missing) == 0 && len(r.PriceDiscrepancies) == 0 &&
+		len(r.StaleExpiries) == 0 && len(r.Expired) == 0
+}
+
+type offerKey struct {
+	provider string
+	root     string
+}
+
+type cidKey struct {
+	provider string
+	cid      string
+}
+
+// Checker collects offers from several gateways and compares them.
+type Checker struct {
+	sampleRate float64
+	threshold  uint64
+	now        func() time.Time
+	gateways   []string
+	counts     map[string]int
+	errors     map[string]string
+	expiries   map[offerKey]map[string]int64
+	prices     map[cidKey]map[string]uint64
+	expired    []ExpiredOffer
+}
+
+// NewChecker creates a checker. With a sample rate below 1 only that fraction of offers is
+// compared. The sample is chosen by hashing the provider and merkle root, so the same offers
+// are sampled on every gateway.
+func NewChecker(sampleRate float64) (*Checker, error) {
+	if sampleRate <= 0 || sampleRate > 1 {
+		return nil, errors.New("Sample rate must be greater than 0 and at most 1")
+	}
+	c := Checker{
+		sampleRate: sampleRate,
+		threshold:  uint64(sampleRate * float64(^uint64(0))),
+		now:        time.Now,
+		counts:     make(map[string]int),
+		errors:     make(map[string]string),
+		expiries:   make(map[offerKey]map[string]int64),
+		prices:     make(map[cidKey]map[string]uint64),
+	}
+	if sampleRate == 1 {
+		c.threshold = ^uint64(0)
+	}
+	return &c, nil
+}
+
+// AddGateway adds a gateway to the comparison. Gateways with no offers must still be added.
+func (c *Checker) AddGateway(gateway string) {
+	c.gateways = append(c.gateways, gateway)
+	c.counts[gateway] = 0
+}
+
+// Failed records that a gateway's offers could not be read. Any of its offers already added
+// are discarded and it is left out of the comparison.
+func (c *Checker) Failed(gateway string, err error) {
+	c.errors[gateway] = err.Error()
+	delete(c.counts, gateway)
+	for _, byGateway := range c.expiries {
+		delete(byGateway, gateway)
+	}
+	for _, byGateway := range c.prices {
+		delete(byGateway, gateway)
+	}
+	expired := c.expired[:0]
+	for _, e := range c.expired {
+		if e.Gateway != gateway {
+			expired = append(expired, e)
+		}
+	}
+	c.expired = expired
+}
+
+// Add records an offer held by a gateway.
+func (c *Checker) Add(gateway string, offer *fcradminmessages.Offer) {
+	key := offerKey{provider: strings.ToLower(offer.ProviderID.ToString()), root: offer.MerkleRoot}
+	if !c.sampled(key) {
+		return
+	}
+	c.counts[gateway]++
+	if c.expiries[key] == nil {
+		c.expiries[key] = make(map[string]int64)
+	}
+	c.expiries[key][gateway] = offer.Expiry
+	if time.Unix(offer.Expiry, 0).Before(c.now()) {
+		c.expired = append(c.expired, ExpiredOffer{Gateway: gateway, ProviderID: key.provider, MerkleRoot: key.root, Expiry: offer.Expiry})
+	}
+	for i := range offer.Cids {
+		ck := cidKey{provider: key.provider, cid: offer.Cids[i].ToString()}
+		if c.prices[ck] == nil {
+			c.prices[ck] = make(map[string]uint64)
+		}
+		c.prices[ck][gateway] = offer.Price
+	}
+}
+
+// Report compares the offers collected.
+func (c *Checker) Report() *Report {
+	r := Report{
+		GeneratedAt:        c.now().UTC(),
+		SampleRate:         c.sampleRate,
+		Gateways:           c.gateways,
+		OfferCounts:        c.counts,
+		Missing:            make([]MissingOffer, 0),
+		PriceDiscrepancies: make([]PriceDiscrepancy, 0),
+		StaleExpiries:      make([]StaleExpiry, 0),
+		Expired:            c.expired,
+	}
+	if len(c.errors) > 0 {
+		r.Errors = c.errors
+	}
+	read := make([]string, 0, len(c.gateways))
+	for _, gw := range c.gateways {
+		if _, failed := c.errors[gw]; !failed {
+			read = append(read, gw)
+		}
+	}
+
+	for key, byGateway := range c.expiries {
+		if len(byGateway) == 0 {
+			continue
+		}
+		present := make([]string, 0, len(byGateway))
+		missing := make([]string, 0)
+		latest := int64(0)
+		stale := false
+		for _, gw := range read {
+			expiry, ok := byGateway[gw]
+			if !ok {
+				missing = append(missing, gw)
+				continue
+			}
+			present = append(present, gw)
+			if len(present) > 1 && expiry != latest {
+				stale = true
+			}
+			if expiry > latest {
+				latest = expiry
+			}
+		}
+		if len(missing) > 0 {
+			r.Missing = append(r.Missing, MissingOffer{ProviderID: key.provider, MerkleRoot: key.root, PresentOn: present, MissingOn: missing})
+		}
+		if stale {
+			r.StaleExpiries = append(r.StaleExpiries, StaleExpiry{ProviderID: key.provider, MerkleRoot: key.root, Expiries: byGateway, Latest: latest})
+		}
+	}
+	for key, byGateway := range c.prices {
+		if !samePrice(byGateway) {
+			r.PriceDiscrepancies = append(r.PriceDiscrepancies, PriceDiscrepancy{ProviderID: key.provider, CID: key.cid, Prices: byGateway})
+		}
+	}
+
+	sort.Slice(r.Missing, func(i, j int) bool {
+		return r.Missing[i].ProviderID+r.Missing[i].MerkleRoot < r.Missing[j].ProviderID+r.Missing[j].MerkleRoot
+	})
+	sort.Slice(r.StaleExpiries, func(i, j int) bool {
+		return r.StaleExpiries[i].ProviderID+r.StaleExpiries[i].MerkleRoot < r.StaleExpiries[j].ProviderID+r.StaleExpiries[j].MerkleRoot
+	})
+	sort.Slice(r.PriceDiscrepancies, func(i, j int) bool {
+		return r.PriceDiscrepancies[i].ProviderID+r.PriceDiscrepancies[i].CID < r.PriceDiscrepancies[j].ProviderID+r.PriceDiscrepancies[j].CID
+	})
+	return &r
+}
+
+func (c *Checker) sampled(key offerKey) bool {
+	if c.sampleRate >= 1 {
+		return true
+	}
+	sum := sha256.Sum256([]byte(key.provider + "/" + key.root))
+	return binary.BigEndian.Uint64(sum[:8]) <= c.threshold
+}
+
+func samePrice(byGateway map[string]uint64) bool {
+	first := true
+	var price uint64
+	for _, p := range byGateway {
+		if first {
+			price, first = p, false
+		} else if p != price {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,218 @@
+package control
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/adminerrors"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/fleetplan"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/inventory"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/tracing"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+var errSkipped = errors.New("Skipped after an earlier action on the gateway failed")
+
+// PlanFleet compares the desired state of the fleet with its live state, read from
+// the register, the inventory and each gateway. Each registered gateway which is not blocked
+// is asked for its status, to learn whether it is initialised and its key version.
+func (g *GatewayManager) PlanFleet(desired *fleetplan.Config) (*fleetplan.Plan, error) {
+	live := fleetplan.LiveState{
+		Registered:  make(map[string]register.GatewayRegister),
+		Managed:     make(map[string]inventory.Gateway),
+		KeyVersions: make(map[string]uint32),
+		Reputation:  make(map[string]map[string]int64),
+	}
+	registered, err := register.GetRegisteredGateways(g.settings.RegisterURL())
+	if err != nil {
+		return nil, adminerrors.New(adminerrors.ErrRegisterFailure, "", fmt.Errorf("Unable to read gateways from register: %s", err))
+	}
+	for _, reg := range registered {
+		live.Registered[strings.ToLower(reg.NodeID)] = reg
+	}
+	managed, err := g.inventory.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, gw := range managed {
+		live.Managed[strings.ToLower(gw.NodeID())] = gw
+	}
+
+	warnings := make([]string, 0)
+	for _, spec := range desired.Gateways {
+		key := strings.ToLower(spec.NodeID)
+		reg, ok := live.Registered[key]
+		if !ok || live.Managed[key].Blocked {
+			continue
+		}
+		version, err := g.gatewayKeyVersion(&reg)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Unable to read the status of gateway %s: %s", spec.NodeID, err))
+			continue
+		}
+		live.KeyVersions[key] = version
+		if len(spec.Reputation) == 0 {
+			continue
+		}
+		reputations := make(map[string]int64, len(spec.Reputation))
+		for client := range spec.Reputation {
+			clientID, err := nodeid.NewNodeIDFromString(client)
+			if err != nil {
+				return nil, err
+			}
+			rep, exists, err := g.GetClientReputation(&reg, clientID)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("Unable to read reputation of client %s from gateway %s: %s", client, spec.NodeID, err))
+				continue
+			}
+			if exists {
+				reputations[strings.ToLower(client)] = rep.Reputation
+			}
+		}
+		live.Reputation[key] = reputations
+	}
+
+	plan := fleetplan.Diff(desired, &live)
+	plan.Warnings = append(warnings, plan.Warnings...)
+	return plan, nil
+}
+
+// gatewayKeyVersion asks a gateway for a page of one client reputation, to learn the key
+// version it signs with. Only an initialised gateway can sign the response.
+func (g *GatewayManager) gatewayKeyVersion(gatewayInfo *register.GatewayRegister) (version uint32, err error) {
+	const operation = "read-gateway-status"
+	start := time.Now()
+	ctx, span := g.tracer.Start(context.Background(), operation, tracing.Gateway(gatewayInfo.NodeID))
+	defer func() {
+		tracing.End(span, err)
+		g.finishOperation(operation, gatewayInfo.NodeID, map[string]string{"key_version": fmt.Sprintf("%d", version)}, start, err)
+	}()
+
+	request, err := fcradminmessages.EncodeAdminListReputationsChallenge(&fcradminmessages.ReputationFilter{Limit: 1})
+	if err != nil {
+		return 0, err
+	}
+	response, err := g.sendAdminRequest(ctx, operation, gatewayInfo, request, fcradminmessages.AdminListReputationsResponseType)
+	if err != nil {
+		return 0, err
+	}
+	return signatureKeyVersion(gatewayInfo.NodeID, response)
+}
+
+// ApplyFleet carries out the actions of a plan. Once an action on a gateway fails, the
+// remaining actions on that gateway are skipped. If dryRun is true no changes are made.
+func (g *GatewayManager) ApplyFleet(plan *fleetplan.Plan, dryRun bool) []fleetplan.Result {
+	results := make([]fleetplan.Result, 0, len(plan.Actions))
+	failed := make(map[string]bool)
+	for _, action := range plan.Actions {
+		key := strings.ToLower(action.Gateway)
+		var err error
+		switch {
+		case dryRun:
+			log.Info("Dry run: %s", action)
+		case failed[key]:
+			err = errSkipped
+		default:
+			log.Info("Applying: %s", action)
+			err = g.applyAction(plan, action)
+		}
+		if err != nil {
+			failed[key] = true
+		}
+		results = append(results, fleetplan.Result{Action: action, Err: err})
+	}
+	return results
+}
+
+func (g *GatewayManager) applyAction(plan *fleetplan.Plan, action fleetplan.Action) error {
+	spec := plan.Spec(action.Gateway)
+	switch action.Kind {
+	case fleetplan.ActionInitialize:
+		return g.initializeWithNewKey(spec.Registration(), spec.KeyVersion)
+	case fleetplan.ActionRekey:
+		gw, err := g.inventory.Get(action.Gateway)
+		if err != nil {
+			return err
+		}
+		return g.initializeWithNewKey(&gw.Register, spec.KeyVersion)
+	case fleetplan.ActionAdopt:
+		reg, ok := plan.Registered(action.Gateway)
+		if !ok {
+			return fmt.Errorf("Gateway %s is not in the register", action.Gateway)
+		}
+		return g.AddGateway(&reg)
+	case fleetplan.ActionBlock:
+		return g.BlockGateway(action.Gateway)
+	case fleetplan.ActionUnblock:
+		return g.UnblockGateway(action.Gateway)
+	case fleetplan.ActionSetTags:
+		gw, err := g.inventory.Get(action.Gateway)
+		if err != nil {
+			return err
+		}
+		removed := make([]string, 0)
+		for k := range gw.Tags {
+			if _, ok := spec.Tags[k]; !ok {
+				removed = append(removed, k)
+			}
+		}
+		if len(removed) > 0 {
+			if err = g.RemoveGatewayTags(action.Gateway, removed); err != nil {
+				return err
+			}
+		}
+		return g.SetGatewayTags(action.Gateway, spec.Tags)
+	case fleetplan.ActionSetReputation:
+		gw, err := g.inventory.Get(action.Gateway)
+		if err != nil {
+			return err
+		}
+		clientID, err := nodeid.NewNodeIDFromString(action.ClientID)
+		if err != nil {
+			return err
+		}
+		return g.SetClientReputation(&gw.Register, clientID, spec.Reputation[action.ClientID])
+	}
+	return fmt.Errorf("Unknown action %s", action.Kind)
+}
+
+// initializeWithNewKey generates a new private key for a gateway and initialises the gateway with it.
+func (g *GatewayManager) initializeWithNewKey(gatewayInfo *register.GatewayRegister, keyVersion uint32) error {
+	gatewayPrivKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	if err != nil {
+		return err
+	}
+	info := *gatewayInfo
+	info.SigningKey, err = gatewayPrivKey.EncodePublicKey()
+	if err != nil {
+		return err
+	}
+	ver := fcrcrypto.InitialKeyVersion()
+	if keyVersion != 0 {
+		ver = fcrcrypto.DecodeKeyVersion(keyVersion)
+	}
+	return g.InitializeGateway(&info, gatewayPrivKey, ver)
+}
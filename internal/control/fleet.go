@@ -0,0 +1,176 @@
+package control
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/adminerrors"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/inventory"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/selector"
+)
+
+// AddGateway adds an already initialised gateway to the inventory of managed gateways,
+// or refreshes its register information if it is already managed.
+func (g *GatewayManager) AddGateway(gatewayInfo *register.GatewayRegister) (err error) {
+	defer func() {
+		g.recordAudit(gatewayInfo.NodeID, "add-gateway", map[string]string{"admin_address": gatewayInfo.NetworkInfoAdmin}, err)
+	}()
+	return g.inventory.Update(gatewayInfo.NodeID, true, func(gw *inventory.Gateway) error {
+		gw.Register = *gatewayInfo
+		return nil
+	})
+}
+
+// RemoveGateway removes a gateway from the inventory of managed gateways.
+func (g *GatewayManager) RemoveGateway(nodeID string) (err error) {
+	defer func() { g.recordAudit(nodeID, "remove-gateway", nil, err) }()
+	if err = g.inventory.Delete(nodeID); err != nil {
+		return err
+	}
+	g.keys.Remove(nodeID)
+	return nil
+}
+
+// GetGateway returns the inventory record of a managed gateway.
+func (g *GatewayManager) GetGateway(nodeID string) (*inventory.Gateway, error) {
+	return g.inventory.Get(nodeID)
+}
+
+// ListGateways returns the inventory records of all managed gateways.
+func (g *GatewayManager) ListGateways() ([]inventory.Gateway, error) {
+	return g.inventory.List()
+}
+
+// BlockGateway marks a managed gateway as blocked. No admin operations are sent to blocked gateways.
+func (g *GatewayManager) BlockGateway(nodeID string) (err error) {
+	defer func() { g.recordAudit(nodeID, "block-gateway", nil, err) }()
+	return g.setBlocked(nodeID, true)
+}
+
+// UnblockGateway clears the blocked flag of a managed gateway.
+func (g *GatewayManager) UnblockGateway(nodeID string) (err error) {
+	defer func() { g.recordAudit(nodeID, "unblock-gateway", nil, err) }()
+	return g.setBlocked(nodeID, false)
+}
+
+func (g *GatewayManager) setBlocked(nodeID string, blocked bool) error {
+	return g.inventory.Update(nodeID, true, func(gw *inventory.Gateway) error {
+		gw.Blocked = blocked
+		return nil
+	})
+}
+
+// checkNotBlocked returns an error if the gateway is blocked.
+func (g *GatewayManager) checkNotBlocked(nodeID string) error {
+	gw, err := g.inventory.Get(nodeID)
+	if err == inventory.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if gw.Blocked {
+		return adminerrors.New(adminerrors.ErrGatewayBlocked, nodeID, nil)
+	}
+	return nil
+}
+
+// recordStatus updates the last status of a managed gateway after an operation.
+func (g *GatewayManager) recordStatus(nodeID string, operation string, opErr error) {
+	status := operation + ": ok"
+	if opErr != nil {
+		status = operation + ": " + opErr.Error()
+	}
+	err := g.inventory.Update(nodeID, false, func(gw *inventory.Gateway) error {
+		gw.LastStatus = status
+		gw.LastStatusAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil && err != inventory.ErrNotFound {
+		log.Error("Error updating inventory status of gateway %s: %s", nodeID, err)
+	}
+}
+
+// SetGatewayTags adds or replaces tags on a managed gateway.
+func (g *GatewayManager) SetGatewayTags(nodeID string, tags map[string]string) (err error) {
+	defer func() { g.recordAudit(nodeID, "tag-gateway", tags, err) }()
+	for k, v := range tags {
+		if err = selector.ValidateTag(k, v); err != nil {
+			return err
+		}
+	}
+	return g.inventory.Update(nodeID, false, func(gw *inventory.Gateway) error {
+		if gw.Tags == nil {
+			gw.Tags = make(map[string]string, len(tags))
+		}
+		for k, v := range tags {
+			gw.Tags[k] = v
+		}
+		return nil
+	})
+}
+
+// RemoveGatewayTags removes tags from a managed gateway.
+func (g *GatewayManager) RemoveGatewayTags(nodeID string, keys []string) (err error) {
+	defer func() {
+		g.recordAudit(nodeID, "untag-gateway", map[string]string{"keys": strings.Join(keys, ",")}, err)
+	}()
+	return g.inventory.Update(nodeID, false, func(gw *inventory.Gateway) error {
+		for _, k := range keys {
+			delete(gw.Tags, k)
+		}
+		return nil
+	})
+}
+
+// SelectGateways returns the managed gateways whose tags match the selector.
+func (g *GatewayManager) SelectGateways(sel string) ([]inventory.Gateway, error) {
+	s, err := selector.Parse(sel)
+	if err != nil {
+		return nil, err
+	}
+	gateways, err := g.inventory.List()
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]inventory.Gateway, 0, len(gateways))
+	for _, gw := range gateways {
+		if s.Matches(gw.Tags) {
+			selected = append(selected, gw)
+		}
+	}
+	return selected, nil
+}
+
+// ForEachGateway runs an admin operation against every managed gateway matching the selector,
+// returning the error, or nil, of the operation for each gateway keyed by node ID. The operation
+// is attempted for every selected gateway even if some of them fail.
+func (g *GatewayManager) ForEachGateway(sel string, op func(gw inventory.Gateway) error) (map[string]error, error) {
+	gateways, err := g.SelectGateways(sel)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]error, len(gateways))
+	for _, gw := range gateways {
+		results[gw.NodeID()] = op(gw)
+	}
+	return results, nil
+}
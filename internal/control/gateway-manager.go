@@ -16,56 +16,164 @@ package control
  */
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
-	"strings"
-	"sync"
+	"net/http"
 	"time"
 
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
-	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrtcpcomms"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
 
 	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/adminerrors"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/audit"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/dialer"
 	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/gatewayapi"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/gatewaykeys"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/inventory"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/metrics"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/redact"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/replay"
 	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/signer"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/tlsconfig"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/tracing"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/transport"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcrkeyexchange"
 )
 
 // GatewayManager managers the pool of gateways and the connections to them.
 type GatewayManager struct {
-	settings      settings.ClientGatewayAdminSettings
-	gateway       ActiveGateway
-	gatewaysLock  sync.RWMutex
-	registeredMap map[string]register.RegisteredNode
-	conxPool      *fcrtcpcomms.CommunicationPool
+	settings       settings.ClientGatewayAdminSettings
+	transport      settings.Transport
+	signer         settings.Signer
+	auditLog       *audit.Log
+	redactor       *redact.Redactor
+	metrics        *metrics.Metrics
+	tracer         *tracing.Tracer
+	registerClient *http.Client
+	replayGuard    *replay.Guard
+	inventory      inventory.Store
+	keys           *gatewaykeys.Cache
+	pins           gatewaykeys.PinStore
 }
 
-// ActiveGateway contains information for a single gateway
-type ActiveGateway struct {
-	comms *gatewayapi.Comms
-}
-
-// NewGatewayManager creates a gateway manager.
-func NewGatewayManager(conf settings.ClientGatewayAdminSettings) *GatewayManager {
+// NewGatewayManager creates a gateway manager. It returns an error if the TLS settings,
+// metrics endpoint, inventory, key pins or audit log can not be set up.
+func NewGatewayManager(conf settings.ClientGatewayAdminSettings) (_ *GatewayManager, err error) {
 	g := GatewayManager{}
+	defer func() {
+		if err != nil {
+			g.release()
+		}
+	}()
 	g.settings = conf
-	g.registeredMap = make(map[string]register.RegisteredNode)
-	g.conxPool = fcrtcpcomms.NewCommunicationPool(&g.registeredMap, &sync.RWMutex{})
-	return &g
+	g.tracer = tracing.New(conf.TracerProvider())
+	g.registerClient = &http.Client{Timeout: registerHTTPTimeout}
+	g.replayGuard = replay.NewGuard(time.Duration(conf.EstablishmentTTL())*time.Second, conf.AllowLegacyResponses())
+	g.signer = conf.Signer()
+	if g.signer == nil {
+		g.signer = signer.NewLocal(conf.GatewayAdminPrivateKey(), conf.GatewayAdminPrivateKeyVer())
+	}
+	g.transport = conf.Transport()
+	if g.transport == nil {
+		g.transport = transport.NewTCP(nil, conf.ConnectionPool())
+	}
+	if conf.TLSEnabled() {
+		tlsBuilder, err := tlsconfig.NewBuilder(tlsconfig.Files{
+			CAFile:     conf.TLSCAFile(),
+			CertFile:   conf.TLSClientCertFile(),
+			KeyFile:    conf.TLSClientKeyFile(),
+			PinnedKeys: conf.TLSPinnedKeys(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to load TLS certificates: %s", err)
+		}
+		t, ok := g.transport.(settings.TLSTransport)
+		if !ok {
+			return nil, errors.New("TLS is enabled but the transport does not support TLS settings")
+		}
+		if err := t.UseTLS(tlsBuilder); err != nil {
+			return nil, fmt.Errorf("Unable to apply TLS settings to the transport: %s", err)
+		}
+	}
+	if p, ok := g.transport.(reconnectingTransport); ok {
+		p.OnReconnect(func(ctx context.Context, gateway string) {
+			operation, _ := ctx.Value(operationKey{}).(string)
+			g.metrics.Retry(operation, gateway)
+		})
+	}
+	if d, ok := g.transport.(dialerUser); ok {
+		d.UseDialers(gatewayDialers(conf))
+	} else if conf.Dialer() != nil || len(conf.GatewayDialers()) > 0 || conf.Resolver() != net.DefaultResolver {
+		log.Warn("Gateway dialers or resolver are set but the transport does not use them")
+	}
+	g.redactor = redact.New(conf.SensitiveLogFields(), conf.UnsafeUnredactedLogging())
+	if g.metrics, err = metrics.New(conf.MetricsRegisterer(), conf.MetricsListenAddress()); err != nil {
+		return nil, fmt.Errorf("Unable to set up metrics: %s", err)
+	}
+	if conf.InventoryPath() != "" {
+		if g.inventory, err = inventory.OpenBolt(conf.InventoryPath(), false); err != nil {
+			return nil, fmt.Errorf("Unable to open gateway inventory %s: %s", conf.InventoryPath(), err)
+		}
+	} else {
+		g.inventory = inventory.NewMemory()
+	}
+	gateways, err := g.inventory.List()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load gateway inventory: %s", err)
+	}
+	log.Info("Loaded %d managed gateways from inventory", len(gateways))
+	if conf.KeyPinPath() != "" {
+		if g.pins, err = gatewaykeys.OpenBolt(conf.KeyPinPath(), false); err != nil {
+			return nil, fmt.Errorf("Unable to open gateway key pins %s: %s", conf.KeyPinPath(), err)
+		}
+	} else {
+		g.pins = gatewaykeys.NewMemory()
+	}
+	g.keys = gatewaykeys.NewCache()
+	if err = g.loadKeys(gateways); err != nil {
+		return nil, fmt.Errorf("Unable to load gateway keys: %s", err)
+	}
+	if conf.AuditLogPath() != "" {
+		if g.auditLog, err = audit.Open(conf.AuditLogPath(), conf.AuditOperator()); err != nil {
+			return nil, fmt.Errorf("Unable to open audit log %s: %s", conf.AuditLogPath(), err)
+		}
+	}
+	return &g, nil
 }
 
 // InitializeGateway initialise a new gateway
-func (g *GatewayManager) InitializeGateway(gatewayInfo *register.GatewayRegister, gatewayPrivKey *fcrcrypto.KeyPair, gatewayPrivKeyVer *fcrcrypto.KeyVersion) error {
+func (g *GatewayManager) InitializeGateway(gatewayInfo *register.GatewayRegister, gatewayPrivKey *fcrcrypto.KeyPair, gatewayPrivKeyVer *fcrcrypto.KeyVersion) (err error) {
+	const operation = "initialize-gateway"
+	start := time.Now()
+	ctx, span := g.tracer.Start(context.Background(), operation, tracing.Gateway(gatewayInfo.NodeID))
+	defer func() {
+		tracing.End(span, err)
+		g.finishOperation(operation, gatewayInfo.NodeID, map[string]string{
+			"admin_address": gatewayInfo.NetworkInfoAdmin,
+			"key_version":   fmt.Sprintf("%d", gatewayPrivKeyVer.EncodeKeyVersion()),
+		}, start, err)
+	}()
+
+	err = g.checkNotBlocked(gatewayInfo.NodeID)
+	if err != nil {
+		return err
+	}
+
 	// TODO check whether gateway not initialized.
 	// TODO check whether contract indicates initialised
-	// TODO: Check given gatewayInfo is correct
-	// First, Get pubkey
-	pubKey, err := gatewayInfo.GetSigningKey()
+	// First, derive the gateway's new signing key from its private key
+	_, step := g.tracer.Start(ctx, "decode-signing-key")
+	signingKey, err := initialSigningKey(gatewayInfo, gatewayPrivKey)
+	tracing.End(step, err)
 	if err != nil {
-		log.Error("Error in obtaining signing key from register info.")
+		log.Error("Error in obtaining signing key: %s", err)
 		return err
 	}
 
@@ -75,97 +183,306 @@ func (g *GatewayManager) InitializeGateway(gatewayInfo *register.GatewayRegister
 		return err
 	}
 
+	endpoint, err := adminEndpoint(nodeID, gatewayInfo.NetworkInfoAdmin) //"gateway:9013"
+	if err != nil {
+		return err
+	}
+
 	// Second, send key exchange to activate the given gateway
-	request, err := fcrmessages.EncodeAdminAcceptKeyChallenge(nodeID, gatewayPrivKey.EncodePrivateKey(), gatewayPrivKeyVer.EncodeKeyVersion())
+	var request *fcrmessages.FCRMessage
+	if g.settings.EncryptGatewayKeys() {
+		exchangeKey, err := g.getExchangeKey(ctx, operation, endpoint)
+		if err != nil {
+			return err
+		}
+		_, step = g.tracer.Start(ctx, "encode-message")
+		request, err = fcrkeyexchange.EncodeAdminAcceptEncryptedKeyChallenge(nodeID, gatewayPrivKey.EncodePrivateKey(), gatewayPrivKeyVer.EncodeKeyVersion(), exchangeKey)
+		tracing.End(step, err)
+	} else {
+		_, step = g.tracer.Start(ctx, "encode-message")
+		request, err = fcrmessages.EncodeAdminAcceptKeyChallenge(nodeID, gatewayPrivKey.EncodePrivateKey(), gatewayPrivKeyVer.EncodeKeyVersion())
+		tracing.End(step, err)
+	}
 	if err != nil {
 		log.Error("Error in encoding message.")
 		return err
 	}
 
-	// Sign the request
-	if request.SignMessage(func(msg interface{}) (string, error) {
-		return fcrcrypto.SignMessage(g.settings.GatewayAdminPrivateKey(), g.settings.GatewayAdminPrivateKeyVer(), msg)
-	}) != nil {
-		log.Error("Error signing message for sending private key to gateway: %+v", err)
+	response, err := g.exchangeMessage(ctx, operation, endpoint, request)
+	if err != nil {
 		return err
 	}
+	if response.MessageType != fcrmessages.AdminAcceptKeyResponseType {
+		// TODO protocol version negotiation needs to be handled.
+		g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+		return unexpectedResponse(operation, gatewayInfo.NodeID, response)
+	}
 
-	log.Info("Sending message to gateway: %v, message: %s", nodeID.ToString(), request.DumpMessage())
-
-	conn, err := g.getConnection(nodeID, gatewayInfo.NetworkInfoAdmin) //"gateway:9013"
+	// The gateway signs with the key it has just been given, so verify with that key
+	// rather than the gateway's known keys.
+	err = g.verifyResponseWithKey(ctx, operation, gatewayInfo.NodeID, gatewayPrivKeyVer.EncodeKeyVersion(), signingKey, response)
 	if err != nil {
 		return err
 	}
-	err = fcrtcpcomms.SendTCPMessage(conn, request, settings.DefaultTCPInactivityTimeout)
+
+	keyAccepted, err := fcrmessages.DecodeAdminAcceptKeyResponse(response)
 	if err != nil {
-		log.Error("Error sending private key to Gateway: %s", err)
+		g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
 		return err
 	}
-
-	// Process the response from the gateway.
-	response, err := fcrtcpcomms.ReadTCPMessage(conn, time.Second*1)
-	log.Info("Response message: %+v", response)
-	if response.MessageType != fcrmessages.AdminAcceptKeyResponseType {
-		// TODO other types of messages such as protocol version negotiation need to be handled.
-		return fmt.Errorf("Unexpected message in response to set-up Gateway message: %d", response.MessageType)
+	if !keyAccepted {
+		rejection, err := fcradminmessages.DecodeAdminAcceptKeyResponseExtension(response)
+		if err != nil {
+			g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+			return err
+		}
+		return &adminerrors.KeyRejectedError{Gateway: gatewayInfo.NodeID, Reason: rejection.Reason, Message: rejection.Message}
 	}
 
-	// Verify the response
-	ok, err := response.VerifySignature(func(sig string, msg interface{}) (bool, error) {
-		return fcrcrypto.VerifyMessage(pubKey, sig, msg)
+	err = g.registerGateway(ctx, gatewayInfo)
+	if err != nil {
+		return adminerrors.New(adminerrors.ErrRegisterFailure, gatewayInfo.NodeID, err)
+	}
+	g.keys.Reset(gatewayInfo.NodeID, gatewayPrivKeyVer.EncodeKeyVersion(), signingKey)
+	err = g.pins.Update(gatewayInfo.NodeID, true, func(p *gatewaykeys.Pin) error {
+		*p = gatewaykeys.Pin{
+			NodeID:     p.NodeID,
+			SigningKey: signingKey,
+			KeyVersion: gatewayPrivKeyVer.EncodeKeyVersion(),
+			Source:     gatewaykeys.SourceInitialize,
+			PinnedAt:   time.Now().UTC(),
+		}
+		return nil
 	})
 	if err != nil {
 		return err
 	}
-	if !ok {
-		return errors.New("Fail to verify the response")
+	return g.inventory.Update(gatewayInfo.NodeID, true, func(gw *inventory.Gateway) error {
+		gw.Register = *gatewayInfo
+		gw.KeyVersion = gatewayPrivKeyVer.EncodeKeyVersion()
+		gw.SigningKeys = map[uint32]string{gw.KeyVersion: signingKey}
+		gw.InitializedAt = time.Now().UTC()
+		return nil
+	})
+}
+
+// getExchangeKey asks an uninitialised gateway for the key its new private key should be
+// encrypted to. The gateway has no signing key yet, so the response can not be verified:
+// Build requires TLS with encrypted keys, which authenticates the gateway.
+func (g *GatewayManager) getExchangeKey(ctx context.Context, operation string, endpoint settings.Endpoint) (*fcrkeyexchange.PublicKey, error) {
+	nodeID := endpoint.NodeID
+	request, err := fcrkeyexchange.EncodeAdminGetExchangeKeyChallenge(nodeID)
+	if err != nil {
+		return nil, err
 	}
+	response, err := g.exchangeMessage(ctx, operation, endpoint, request)
+	if err != nil {
+		return nil, err
+	}
+	exchangeKey, err := fcrkeyexchange.DecodeAdminGetExchangeKeyResponse(response)
+	if err != nil {
+		g.metrics.ProtocolError(operation, nodeID.ToString())
+		return nil, err
+	}
+	return exchangeKey, nil
+}
 
-	keyAccepted, err := fcrmessages.DecodeAdminAcceptKeyResponse(response)
+// exchangeMessage stamps a request with a nonce and expiry, signs it, sends it to a
+// gateway over the transport and reads the response, which must echo the nonce.
+func (g *GatewayManager) exchangeMessage(ctx context.Context, operation string, endpoint settings.Endpoint, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+	nodeID := endpoint.NodeID
+	stamp, err := g.replayGuard.Stamp(request)
 	if err != nil {
-		return err
+		log.Error("Error adding nonce to message for gateway %v: %s", nodeID.ToString(), err)
+		return nil, err
 	}
-	if !keyAccepted {
-		return fmt.Errorf("Key not accepted for unspecified reason")
+
+	// Sign the request
+	signCtx, step := g.tracer.Start(ctx, "sign-message")
+	err = request.SignMessage(func(interface{}) (string, error) {
+		return g.signer.Sign(signCtx, request)
+	})
+	tracing.End(step, err)
+	if err != nil {
+		log.Error("Error signing message for gateway %v: %+v", nodeID.ToString(), err)
+		return nil, err
 	}
 
-	return gatewayInfo.RegisterGateway(g.settings.RegisterURL())
+	log.Info("Sending message to gateway: %v, message: %s", nodeID.ToString(), g.redactor.Message(request))
+	exchangeCtx, step := g.tracer.Start(context.WithValue(ctx, operationKey{}, operation), "exchange")
+	response, err := g.transport.Exchange(exchangeCtx, endpoint, request)
+	tracing.End(step, err)
+	if err != nil {
+		log.Error("Error exchanging message with Gateway %v: %s", nodeID.ToString(), err)
+		g.metrics.SetReachable(nodeID.ToString(), false)
+		return nil, adminerrors.New(adminerrors.ErrGatewayUnreachable, nodeID.ToString(), err)
+	}
+	g.metrics.SetReachable(nodeID.ToString(), true)
+	log.Info("Response message: %s", g.redactor.Message(response))
+
+	err = g.replayGuard.Check(response, stamp)
+	if err != nil {
+		log.Error("Rejected response from gateway %v: %s", nodeID.ToString(), err)
+		g.metrics.ProtocolError(operation, nodeID.ToString())
+		return nil, err
+	}
+	return response, nil
 }
 
-// BlockGateway adds a host to disallowed list of gateways
-func (g *GatewayManager) BlockGateway(hostName string) {
-	// TODO
+// sendAdminRequest sends a request to an initialised gateway and returns its response, once the
+// response has been checked to be of the expected type and signed by the gateway's key.
+func (g *GatewayManager) sendAdminRequest(ctx context.Context, operation string, gatewayInfo *register.GatewayRegister, request *fcrmessages.FCRMessage, responseType int32) (*fcrmessages.FCRMessage, error) {
+	err := g.checkNotBlocked(gatewayInfo.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	nodeID, err := nodeid.NewNodeIDFromString(gatewayInfo.NodeID)
+	if err != nil {
+		log.Error("Error in generating nodeID.")
+		return nil, err
+	}
+
+	endpoint, err := adminEndpoint(nodeID, gatewayInfo.NetworkInfoAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := g.exchangeMessage(ctx, operation, endpoint, request)
+	if err != nil {
+		return nil, err
+	}
+	if response.MessageType != responseType {
+		g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+		return nil, unexpectedResponse(operation, gatewayInfo.NodeID, response)
+	}
+	err = g.verifyResponse(ctx, operation, gatewayInfo, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
 }
 
-// UnblockGateway add a host to allowed list of gateways
-func (g *GatewayManager) UnblockGateway(hostName string) {
-	// TODO
+// unexpectedResponse returns the error for a response of the wrong type. Protocol change
+// and mismatch responses mean the gateway does not speak this client's protocol version.
+func unexpectedResponse(operation string, gateway string, response *fcrmessages.FCRMessage) error {
+	switch response.MessageType {
+	case fcrmessages.ProtocolChangeResponseType, fcrmessages.ProtocolMismatchResposneType:
+		return adminerrors.New(adminerrors.ErrProtocolMismatch, gateway,
+			fmt.Errorf("Gateway responded to %s message with message type %d", operation, response.MessageType))
+	}
+	return &adminerrors.UnexpectedMessageError{Gateway: gateway, Operation: operation, MessageType: response.MessageType}
+}
 
+// ConnectionPoolStats returns the statistics of the admin connection pool. It returns false
+// if the transport does not pool connections.
+func (g *GatewayManager) ConnectionPoolStats() (transport.PoolStats, bool) {
+	p, ok := g.transport.(interface{ PoolStats() transport.PoolStats })
+	if !ok {
+		return transport.PoolStats{}, false
+	}
+	return p.PoolStats(), true
 }
 
 // Shutdown stops go routines and closes sockets. This should be called as part
 // of the graceful library shutdown
 func (g *GatewayManager) Shutdown() {
-	// TODO
+	g.release()
 }
 
-func (g *GatewayManager) getConnection(gatewayNodeID *nodeid.NodeID, addr string) (net.Conn, error) {
-	// Add new gateway to the connection pool.
-	g.registeredMap[strings.ToLower(gatewayNodeID.ToString())] = &register.GatewayRegister{
-		NodeID:             gatewayNodeID.ToString(),
-		NetworkInfoGateway: addr,
+// release closes whatever the gateway manager has opened so far.
+func (g *GatewayManager) release() {
+	if g.metrics != nil {
+		g.metrics.Shutdown()
+	}
+	if g.transport != nil {
+		if err := g.transport.Close(); err != nil {
+			log.Error("Error closing gateway transport: %s", err)
+		}
+	}
+	if g.inventory != nil {
+		if err := g.inventory.Close(); err != nil {
+			log.Error("Error closing gateway inventory: %s", err)
+		}
+	}
+	if g.pins != nil {
+		if err := g.pins.Close(); err != nil {
+			log.Error("Error closing gateway key pins: %s", err)
+		}
 	}
+}
 
-	// Get conn for the right gateway
-	channel, err := g.conxPool.GetConnForRequestingNode(gatewayNodeID, fcrtcpcomms.AccessFromGateway)
-	if err != nil {
-		return nil, err
+// finishOperation records the outcome of an admin operation in the audit log and metrics.
+func (g *GatewayManager) finishOperation(operation string, gateway string, params map[string]string, start time.Time, opErr error) {
+	g.recordAudit(gateway, operation, params, opErr)
+	g.metrics.ObserveOperation(operation, gateway, start, opErr)
+	g.recordStatus(gateway, operation, opErr)
+	if pool, ok := g.transport.(connectionCounter); ok {
+		g.metrics.SetPooledConnections(pool.Connections())
 	}
-	conn := channel.Conn
-	if err != nil {
-		log.Error("Error getting a connection to gateway %v: %s", gatewayNodeID.ToString(), err)
-		return nil, err
+}
+
+// recordAudit writes an entry to the audit log, if one is configured.
+func (g *GatewayManager) recordAudit(gateway string, operation string, params map[string]string, opErr error) {
+	if g.auditLog == nil {
+		return
+	}
+	if err := g.auditLog.Record(gateway, operation, params, opErr); err != nil {
+		log.Error("Error writing audit log entry for %s on gateway %s: %s", operation, gateway, err)
 	}
-	return conn, nil
+}
+
+// connectionCounter is implemented by transports which hold connections open to gateways.
+type connectionCounter interface {
+	Connections() int
+}
 
+// reconnectingTransport is implemented by transports which resend requests after a broken connection.
+type reconnectingTransport interface {
+	OnReconnect(fn func(ctx context.Context, gateway string))
+}
+
+// operationKey is the context key of the operation a message is being exchanged for.
+type operationKey struct{}
+
+// dialerUser is implemented by transports which connect to gateways through the dialers in settings.
+type dialerUser interface {
+	UseDialers(dialerFor func(nodeID string) settings.Dialer)
+}
+
+// resolverUser is implemented by dialers which look up host names, such as a proxy's.
+type resolverUser interface {
+	UseResolver(r settings.Resolver)
+}
+
+// gatewayDialers returns the function giving the dialer for each gateway: its dialer in
+// settings, or a direct dialer which looks up host names with the settings resolver.
+// Proxy dialers are given the resolver to look up the proxy's host name.
+func gatewayDialers(conf settings.ClientGatewayAdminSettings) func(nodeID string) settings.Dialer {
+	dialers := []settings.Dialer{conf.Dialer()}
+	for _, d := range conf.GatewayDialers() {
+		dialers = append(dialers, d)
+	}
+	for _, d := range dialers {
+		if r, ok := d.(resolverUser); ok {
+			r.UseResolver(conf.Resolver())
+		}
+	}
+	direct := &dialer.Resolving{Resolver: conf.Resolver(), KeepAlive: conf.ConnectionPool().WithDefaults().KeepAlive}
+	return func(nodeID string) settings.Dialer {
+		if d := conf.DialerFor(nodeID); d != nil {
+			return d
+		}
+		return direct
+	}
+}
+
+// adminEndpoint returns the endpoint of a gateway's admin interface. The host name is
+// looked up with the settings resolver when the gateway is dialled.
+func adminEndpoint(nodeID *nodeid.NodeID, addr string) (settings.Endpoint, error) {
+	adminAddr, err := gatewayapi.ParseAddress(addr, settings.DefaultGatewayAdminPort)
+	if err != nil {
+		log.Error("Invalid admin address for gateway %v: %s", nodeID.ToString(), err)
+		return settings.Endpoint{}, err
+	}
+	return settings.Endpoint{NodeID: nodeID, Address: adminAddr.HostPort()}, nil
 }
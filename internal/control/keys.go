@@ -0,0 +1,365 @@
+package control
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/adminerrors"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/gatewaykeys"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/inventory"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/tracing"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+// minSignatureLength is the length of the hex encoded key version at the start of a signature.
+const minSignatureLength = 8
+
+// verifyResponse checks that a response is signed by the gateway's key for the key version in
+// the signature. A key version which is not yet known is verified with the key in gatewayInfo,
+// and then with the key in the register, in case the gateway has rotated its key since
+// gatewayInfo was read. A key which verifies the response but conflicts with the gateway's
+// known keys, or is not its pinned key, is reported as an unexpected key change.
+func (g *GatewayManager) verifyResponse(ctx context.Context, operation string, gatewayInfo *register.GatewayRegister, response *fcrmessages.FCRMessage) (err error) {
+	gateway := gatewayInfo.NodeID
+	_, step := g.tracer.Start(ctx, "verify-signature")
+	defer func() {
+		tracing.End(step, err)
+		if errors.Is(err, adminerrors.ErrSignatureInvalid) {
+			g.metrics.SignatureFailure(operation, gateway)
+		}
+	}()
+
+	version, err := signatureKeyVersion(gateway, response)
+	if err != nil {
+		return err
+	}
+
+	known, ok := g.keys.Get(gateway, version)
+	if ok && verifyWithKey(known, response) {
+		return nil
+	}
+	if gatewayInfo.SigningKey != known && verifyWithKey(gatewayInfo.SigningKey, response) {
+		return g.learnKey(gateway, version, gatewayInfo.SigningKey)
+	}
+
+	registered, err := g.registeredSigningKey(gateway)
+	if err != nil {
+		return err
+	}
+	if registered != known && registered != gatewayInfo.SigningKey && verifyWithKey(registered, response) {
+		return g.learnKey(gateway, version, registered)
+	}
+	return adminerrors.New(adminerrors.ErrSignatureInvalid, gateway,
+		fmt.Errorf("Fail to verify the response with any key for key version %d", version))
+}
+
+// verifyResponseWithKey checks that a response is signed by the given encoded key for the
+// given key version. It is used when the gateway's key is not yet known, as when the gateway
+// is initialised with it.
+func (g *GatewayManager) verifyResponseWithKey(ctx context.Context, operation string, gateway string, version uint32, encodedKey string, response *fcrmessages.FCRMessage) (err error) {
+	_, step := g.tracer.Start(ctx, "verify-signature")
+	defer func() {
+		tracing.End(step, err)
+		if err != nil {
+			g.metrics.SignatureFailure(operation, gateway)
+		}
+	}()
+
+	signedVersion, err := signatureKeyVersion(gateway, response)
+	if err != nil {
+		return err
+	}
+	if signedVersion != version {
+		return adminerrors.New(adminerrors.ErrSignatureInvalid, gateway,
+			fmt.Errorf("Response signed with key version %d, expected key version %d", signedVersion, version))
+	}
+	if !verifyWithKey(encodedKey, response) {
+		return adminerrors.New(adminerrors.ErrSignatureInvalid, gateway, errors.New("Fail to verify the response"))
+	}
+	return nil
+}
+
+// signatureKeyVersion returns the key version at the start of a response signature. The
+// signature length is checked first, as fcrcrypto panics on a signature too short to hold one.
+func signatureKeyVersion(gateway string, response *fcrmessages.FCRMessage) (uint32, error) {
+	if len(response.Signature) < minSignatureLength {
+		return 0, adminerrors.New(adminerrors.ErrSignatureInvalid, gateway, errors.New("Response signature is too short"))
+	}
+	keyVersion, err := fcrcrypto.ExtractKeyVersionFromMessage(response.Signature)
+	if err != nil {
+		return 0, adminerrors.New(adminerrors.ErrSignatureInvalid, gateway, err)
+	}
+	return keyVersion.EncodeKeyVersion(), nil
+}
+
+// initialSigningKey returns the encoded public key of the private key a gateway is being
+// initialised with, after checking that it is the signing key in the gateway's register
+// information.
+func initialSigningKey(gatewayInfo *register.GatewayRegister, gatewayPrivKey *fcrcrypto.KeyPair) (string, error) {
+	expected, err := gatewayPrivKey.EncodePublicKey()
+	if err != nil {
+		return "", err
+	}
+	registered, err := gatewayInfo.GetSigningKey()
+	if err != nil {
+		return "", err
+	}
+	encoded, err := registered.EncodePublicKey()
+	if err != nil {
+		return "", err
+	}
+	if encoded != expected {
+		return "", fmt.Errorf("Signing key of gateway %s in the register information is not the public key of its new private key", gatewayInfo.NodeID)
+	}
+	return expected, nil
+}
+
+// verifyWithKey returns true if the response's digest, which covers its type and body, is
+// signed by the encoded public key.
+func verifyWithKey(encodedKey string, response *fcrmessages.FCRMessage) bool {
+	if encodedKey == "" {
+		return false
+	}
+	pubKey, err := fcrcrypto.DecodePublicKey(encodedKey)
+	if err != nil {
+		return false
+	}
+	ok, err := fcradminmessages.VerifyMessage(pubKey, response)
+	return err == nil && ok
+}
+
+// registeredSigningKey reads the gateway's current signing key from the register.
+func (g *GatewayManager) registeredSigningKey(gateway string) (string, error) {
+	gateways, err := register.GetRegisteredGateways(g.settings.RegisterURL())
+	if err != nil {
+		return "", adminerrors.New(adminerrors.ErrRegisterFailure, gateway, fmt.Errorf("Unable to read gateways from register: %s", err))
+	}
+	for _, gw := range gateways {
+		if strings.EqualFold(gw.NodeID, gateway) {
+			return gw.SigningKey, nil
+		}
+	}
+	return "", nil
+}
+
+// learnKey records a key which has verified a response from the gateway, unless it conflicts
+// with the gateway's known keys or is not the gateway's pinned key.
+func (g *GatewayManager) learnKey(gateway string, version uint32, key string) error {
+	latest, ok := g.keys.Latest(gateway)
+	if known, _ := g.keys.Get(gateway, version); (ok && version < latest) || (known != "" && known != key) {
+		g.holdPendingKey(gateway, version, key)
+		log.Error("Gateway %s signed a response with an unexpected key for key version %d (latest known key version %d). The gateway may be impersonated.", gateway, version, latest)
+		g.metrics.KeyChange(gateway)
+		return &adminerrors.KeyChangedError{Gateway: gateway, KeyVersion: version, LatestVersion: latest}
+	}
+	firstUse, err := g.checkPin(gateway, version, key)
+	if err != nil {
+		return err
+	}
+	if firstUse {
+		log.Info("Pinned signing key version %d of gateway %s on first use", version, gateway)
+		g.keys.Reset(gateway, version, key)
+	} else {
+		g.keys.Add(gateway, version, key)
+		if ok && version > latest {
+			log.Info("Gateway %s has rotated its signing key from key version %d to %d", gateway, latest, version)
+		}
+	}
+
+	err = g.inventory.Update(gateway, false, func(gw *inventory.Gateway) error {
+		if firstUse || gw.SigningKeys == nil {
+			gw.SigningKeys = make(map[uint32]string)
+		}
+		gw.SigningKeys[version] = key
+		if version > gw.KeyVersion {
+			gw.KeyVersion = version
+			gw.Register.SigningKey = key
+		}
+		return nil
+	})
+	if err != nil && err != inventory.ErrNotFound {
+		log.Error("Error storing signing key of gateway %s in inventory: %s", gateway, err)
+	}
+	return nil
+}
+
+// checkPin checks a key against the gateway's pinned key, pinning the key if the gateway has
+// none. It returns true if the key was pinned on first use. A key other than the pinned key
+// is held as pending until an operator approves it.
+func (g *GatewayManager) checkPin(gateway string, version uint32, key string) (bool, error) {
+	firstUse, mismatch := false, false
+	var pinnedVersion uint32
+	err := g.pins.Update(gateway, true, func(p *gatewaykeys.Pin) error {
+		now := time.Now().UTC()
+		switch {
+		case !p.Pinned():
+			*p = gatewaykeys.Pin{NodeID: p.NodeID, SigningKey: key, KeyVersion: version, Source: gatewaykeys.SourceFirstUse, PinnedAt: now}
+			firstUse = true
+		case p.SigningKey != key:
+			if p.PendingKey != key || p.PendingVersion != version {
+				p.PendingKey, p.PendingVersion, p.PendingAt = key, version, now
+			}
+			mismatch, pinnedVersion = true, p.KeyVersion
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("Unable to check pinned key of gateway %s: %s", gateway, err)
+	}
+	if mismatch {
+		log.Error("Gateway %s signed a response with key version %d, which is not its pinned key. The gateway may be impersonated. Approve the key change if it is expected.", gateway, version)
+		g.metrics.KeyChange(gateway)
+		return false, &adminerrors.UnapprovedKeyError{Gateway: gateway, KeyVersion: version, PinnedVersion: pinnedVersion}
+	}
+	return firstUse, nil
+}
+
+// holdPendingKey holds a key which conflicts with the gateway's known keys as pending, so an
+// operator can approve it.
+func (g *GatewayManager) holdPendingKey(gateway string, version uint32, key string) {
+	err := g.pins.Update(gateway, false, func(p *gatewaykeys.Pin) error {
+		p.PendingKey, p.PendingVersion, p.PendingAt = key, version, time.Now().UTC()
+		return nil
+	})
+	if err != nil && err != gatewaykeys.ErrNotPinned {
+		log.Error("Error holding pending key of gateway %s: %s", gateway, err)
+	}
+}
+
+// loadKeys fills the key cache from the pinned keys and the known keys of managed gateways,
+// and pins the latest key of any managed gateway which has never had a pin. The known keys of
+// a gateway whose pin has been revoked are not loaded.
+func (g *GatewayManager) loadKeys(gateways []inventory.Gateway) error {
+	pins, err := g.pins.List()
+	if err != nil {
+		return err
+	}
+	hasPin := make(map[string]bool, len(pins))
+	revoked := make(map[string]bool)
+	for _, p := range pins {
+		hasPin[strings.ToLower(p.NodeID)] = true
+		if p.Pinned() {
+			g.keys.Add(p.NodeID, p.KeyVersion, p.SigningKey)
+		} else {
+			revoked[strings.ToLower(p.NodeID)] = true
+		}
+	}
+
+	for _, gw := range gateways {
+		nodeID := gw.NodeID()
+		if revoked[strings.ToLower(nodeID)] {
+			continue
+		}
+		for version, key := range gw.SigningKeys {
+			g.keys.Add(nodeID, version, key)
+		}
+		if _, ok := gw.SigningKeys[gw.KeyVersion]; !ok && gw.KeyVersion != 0 && gw.Register.SigningKey != "" {
+			g.keys.Add(nodeID, gw.KeyVersion, gw.Register.SigningKey)
+		}
+		if hasPin[strings.ToLower(nodeID)] {
+			continue
+		}
+		version, ok := g.keys.Latest(nodeID)
+		if !ok {
+			continue
+		}
+		key, _ := g.keys.Get(nodeID, version)
+		err = g.pins.Update(nodeID, true, func(p *gatewaykeys.Pin) error {
+			*p = gatewaykeys.Pin{NodeID: p.NodeID, SigningKey: key, KeyVersion: version, Source: gatewaykeys.SourceFirstUse, PinnedAt: time.Now().UTC()}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListKeyPins returns the signing keys pinned for each gateway, with any pending key changes.
+func (g *GatewayManager) ListKeyPins() ([]gatewaykeys.Pin, error) {
+	return g.pins.List()
+}
+
+// ApproveGatewayKey approves the pending key change of a gateway, pinning the new key. The
+// gateway's other known keys are retired, so responses signed with them are rejected, unless
+// keepOld is true.
+func (g *GatewayManager) ApproveGatewayKey(nodeID string, keepOld bool) (err error) {
+	var pin *gatewaykeys.Pin
+	defer func() {
+		params := map[string]string{"keep_old": fmt.Sprintf("%t", keepOld)}
+		if pin != nil {
+			params["key_version"] = fmt.Sprintf("%d", pin.KeyVersion)
+		}
+		g.recordAudit(nodeID, "approve-key", params, err)
+	}()
+	pin, err = gatewaykeys.Approve(g.pins, nodeID)
+	if err != nil {
+		return err
+	}
+	if keepOld {
+		g.keys.Set(nodeID, pin.KeyVersion, pin.SigningKey)
+	} else {
+		g.keys.Reset(nodeID, pin.KeyVersion, pin.SigningKey)
+	}
+	err = g.inventory.Update(nodeID, false, func(gw *inventory.Gateway) error {
+		if gw.SigningKeys == nil || !keepOld {
+			gw.SigningKeys = make(map[uint32]string)
+		}
+		gw.SigningKeys[pin.KeyVersion] = pin.SigningKey
+		if pin.KeyVersion >= gw.KeyVersion || !keepOld {
+			gw.KeyVersion = pin.KeyVersion
+			gw.Register.SigningKey = pin.SigningKey
+		}
+		return nil
+	})
+	if err == inventory.ErrNotFound {
+		err = nil
+	}
+	return err
+}
+
+// RevokeGatewayKey revokes the pinned key of a gateway and forgets its known keys. The next
+// key seen for the gateway is pinned as on first use.
+func (g *GatewayManager) RevokeGatewayKey(nodeID string) (err error) {
+	defer func() { g.recordAudit(nodeID, "revoke-key", nil, err) }()
+	if err = gatewaykeys.Revoke(g.pins, nodeID); err != nil {
+		return err
+	}
+	g.keys.Remove(nodeID)
+	err = g.inventory.Update(nodeID, false, func(gw *inventory.Gateway) error {
+		gw.SigningKeys = nil
+		return nil
+	})
+	if err == inventory.ErrNotFound {
+		err = nil
+	}
+	return err
+}
+
+// GatewayKeys returns the known signing keys of a gateway, by key version.
+func (g *GatewayManager) GatewayKeys(nodeID string) map[uint32]string {
+	return g.keys.Keys(nodeID)
+}
@@ -0,0 +1,289 @@
+package control
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/adminerrors"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/gatewaykeys"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+const testGateway = "0102030405"
+
+// testKey is a gateway signing key with its key version.
+type testKey struct {
+	pair    *fcrcrypto.KeyPair
+	version uint32
+	encoded string
+}
+
+func newTestKey(t *testing.T, version uint32) *testKey {
+	t.Helper()
+	pair, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := pair.EncodePublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testKey{pair: pair, version: version, encoded: encoded}
+}
+
+// response returns a response signed with the key, from a gateway whose register
+// information holds the key.
+func (k *testKey) response(t *testing.T) (*register.GatewayRegister, *fcrmessages.FCRMessage) {
+	t.Helper()
+	response := &fcrmessages.FCRMessage{
+		MessageType:       fcradminmessages.AdminListOffersResponseType,
+		ProtocolVersion:   1,
+		ProtocolSupported: []int32{1, 1},
+		MessageBody:       []byte(fmt.Sprintf(`{"offers":[],"next":"","nonce":"aa%d","expiry":100}`, k.version)),
+	}
+	if err := fcradminmessages.SignMessage(k.pair, fcrcrypto.DecodeKeyVersion(k.version), response); err != nil {
+		t.Fatal(err)
+	}
+	return &register.GatewayRegister{NodeID: testGateway, SigningKey: k.encoded}, response
+}
+
+// newTestManager creates a gateway manager with its inventory and key pins in dir. The
+// register lists no gateways.
+func newTestManager(t *testing.T, dir string) *GatewayManager {
+	t.Helper()
+	reg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	t.Cleanup(reg.Close)
+
+	builder := settings.CreateSettings()
+	builder.SetRegisterURL(reg.URL)
+	builder.SetInventoryPath(filepath.Join(dir, "inventory.db"))
+	builder.SetKeyPinPath(filepath.Join(dir, "keypins.db"))
+	g, err := NewGatewayManager(*builder.Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// verify verifies a response signed with the key.
+func verify(t *testing.T, g *GatewayManager, k *testKey) error {
+	t.Helper()
+	gatewayInfo, response := k.response(t)
+	return g.verifyResponse(context.Background(), "test", gatewayInfo, response)
+}
+
+// pin returns the pin of the test gateway.
+func pin(t *testing.T, g *GatewayManager) *gatewaykeys.Pin {
+	t.Helper()
+	p, err := g.pins.Get(testGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestVerifyResponsePinsKeyOnFirstUse(t *testing.T) {
+	g := newTestManager(t, t.TempDir())
+	defer g.Shutdown()
+	key := newTestKey(t, 1)
+
+	if err := verify(t, g, key); err != nil {
+		t.Fatal(err)
+	}
+	p := pin(t, g)
+	if p.SigningKey != key.encoded || p.KeyVersion != 1 || p.Source != gatewaykeys.SourceFirstUse {
+		t.Errorf("pin is %+v, want key version 1 pinned on first use", p)
+	}
+}
+
+func TestVerifyResponseRejectsForgedBody(t *testing.T) {
+	g := newTestManager(t, t.TempDir())
+	defer g.Shutdown()
+	key := newTestKey(t, 1)
+	if err := verify(t, g, key); err != nil {
+		t.Fatal(err)
+	}
+
+	gatewayInfo, response := key.response(t)
+	response.MessageBody = []byte(`{"offers":[{"forged":true}],"next":"","nonce":"aa1","expiry":100}`)
+	err := g.verifyResponse(context.Background(), "test", gatewayInfo, response)
+	if !errors.Is(err, adminerrors.ErrSignatureInvalid) {
+		t.Fatalf("verifying a forged body returned %v, want an invalid signature", err)
+	}
+}
+
+func TestVerifyResponseRejectsUnpinnedKey(t *testing.T) {
+	g := newTestManager(t, t.TempDir())
+	defer g.Shutdown()
+	pinned, other := newTestKey(t, 1), newTestKey(t, 2)
+	if err := verify(t, g, pinned); err != nil {
+		t.Fatal(err)
+	}
+
+	err := verify(t, g, other)
+	var unapproved *adminerrors.UnapprovedKeyError
+	if !errors.As(err, &unapproved) {
+		t.Fatalf("verifying with a key other than the pinned key returned %v, want an unapproved key", err)
+	}
+	if unapproved.KeyVersion != 2 || unapproved.PinnedVersion != 1 {
+		t.Errorf("error is %+v, want key version 2 and pinned version 1", unapproved)
+	}
+	p := pin(t, g)
+	if p.SigningKey != pinned.encoded || p.PendingKey != other.encoded || p.PendingVersion != 2 {
+		t.Errorf("pin is %+v, want key version 1 pinned and key version 2 pending", p)
+	}
+	if err := verify(t, g, other); !errors.Is(err, adminerrors.ErrKeyChanged) {
+		t.Errorf("verifying with the pending key again returned %v, want a key change", err)
+	}
+}
+
+func TestApproveGatewayKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		keepOld bool
+	}{
+		{"retire old keys", false},
+		{"keep old keys", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newTestManager(t, t.TempDir())
+			defer g.Shutdown()
+			old, rotated := newTestKey(t, 1), newTestKey(t, 2)
+			if err := verify(t, g, old); err != nil {
+				t.Fatal(err)
+			}
+			if err := verify(t, g, rotated); err == nil {
+				t.Fatal("verified a response signed with an unapproved key")
+			}
+
+			if err := g.ApproveGatewayKey(testGateway, test.keepOld); err != nil {
+				t.Fatal(err)
+			}
+			p := pin(t, g)
+			if p.SigningKey != rotated.encoded || p.KeyVersion != 2 || p.Source != gatewaykeys.SourceApproved || p.PendingKey != "" {
+				t.Errorf("pin is %+v, want key version 2 approved", p)
+			}
+			if err := verify(t, g, rotated); err != nil {
+				t.Errorf("verifying with the approved key returned %v", err)
+			}
+			err := verify(t, g, old)
+			if test.keepOld && err != nil {
+				t.Errorf("verifying with a kept old key returned %v", err)
+			}
+			if !test.keepOld && !errors.Is(err, adminerrors.ErrKeyChanged) {
+				t.Errorf("verifying with a retired key returned %v, want a key change", err)
+			}
+		})
+	}
+}
+
+func TestApproveGatewayKeyWithoutPendingKey(t *testing.T) {
+	g := newTestManager(t, t.TempDir())
+	defer g.Shutdown()
+	if err := verify(t, g, newTestKey(t, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApproveGatewayKey(testGateway, false); err == nil {
+		t.Error("approved a key change which is not pending")
+	}
+}
+
+func TestRevokeGatewayKey(t *testing.T) {
+	g := newTestManager(t, t.TempDir())
+	defer g.Shutdown()
+	revoked, next := newTestKey(t, 1), newTestKey(t, 2)
+	if err := verify(t, g, revoked); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.RevokeGatewayKey(testGateway); err != nil {
+		t.Fatal(err)
+	}
+	if keys := g.GatewayKeys(testGateway); len(keys) != 0 {
+		t.Errorf("gateway has known keys %v after its key was revoked", keys)
+	}
+	if p := pin(t, g); p.Pinned() || p.RevokedAt.IsZero() {
+		t.Errorf("pin is %+v, want it revoked", p)
+	}
+	if err := verify(t, g, next); err != nil {
+		t.Fatalf("verifying with a new key after revocation returned %v", err)
+	}
+	if p := pin(t, g); p.SigningKey != next.encoded || p.Source != gatewaykeys.SourceFirstUse {
+		t.Errorf("pin is %+v, want the new key pinned on first use", p)
+	}
+}
+
+func TestKeysReloadAfterRestart(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, g *GatewayManager)
+		// want is the key version the gateway is pinned to after the restart, or 0 if none.
+		want uint32
+	}{
+		{"first use", func(t *testing.T, g *GatewayManager) {}, 1},
+		{"approved", func(t *testing.T, g *GatewayManager) {
+			if err := g.ApproveGatewayKey(testGateway, false); err != nil {
+				t.Fatal(err)
+			}
+		}, 2},
+		{"revoked", func(t *testing.T, g *GatewayManager) {
+			if err := g.RevokeGatewayKey(testGateway); err != nil {
+				t.Fatal(err)
+			}
+		}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			keys := map[uint32]*testKey{1: newTestKey(t, 1), 2: newTestKey(t, 2)}
+			g := newTestManager(t, dir)
+			if err := verify(t, g, keys[1]); err != nil {
+				t.Fatal(err)
+			}
+			if err := verify(t, g, keys[2]); err == nil {
+				t.Fatal("verified a response signed with an unapproved key")
+			}
+			test.change(t, g)
+			g.Shutdown()
+
+			g = newTestManager(t, dir)
+			defer g.Shutdown()
+			known := g.GatewayKeys(testGateway)
+			if test.want == 0 {
+				if len(known) != 0 {
+					t.Errorf("gateway has known keys %v after restart, want none", known)
+				}
+				return
+			}
+			if len(known) != 1 || known[test.want] != keys[test.want].encoded {
+				t.Errorf("gateway has known keys %v after restart, want only key version %d", known, test.want)
+			}
+			if p := pin(t, g); p.SigningKey != keys[test.want].encoded {
+				t.Errorf("pin is %+v after restart, want key version %d", p, test.want)
+			}
+			// Without the key in the register information, the response can only verify
+			// against the reloaded key.
+			gatewayInfo, response := keys[test.want].response(t)
+			gatewayInfo.SigningKey = ""
+			if err := g.verifyResponse(context.Background(), "test", gatewayInfo, response); err != nil {
+				t.Errorf("verifying with the reloaded key returned %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package control
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/adminerrors"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/consistency"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/offerverify"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/tracing"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+// OfferAck is a gateway's signed acknowledgement of an offer operation. Signature is the
+// gateway's signature of the acknowledgement message, kept as a record of the operation.
+type OfferAck struct {
+	fcradminmessages.AdminOfferAck
+	Signature string `json:"signature"`
+}
+
+// EvictOffers asks a gateway to remove a provider's offers from its cache. If merkleRoots is
+// empty all of the provider's offers are removed.
+func (g *GatewayManager) EvictOffers(gatewayInfo *register.GatewayRegister, providerID *nodeid.NodeID, merkleRoots []string) (*OfferAck, error) {
+	request, err := fcradminmessages.EncodeAdminEvictOffersChallenge(providerID, merkleRoots)
+	if err != nil {
+		return nil, err
+	}
+	return g.offerOperation("evict-offers", gatewayInfo, providerID, request, map[string]string{
+		"merkle_roots": strings.Join(merkleRoots, ","),
+	})
+}
+
+// PinOffers asks a gateway to keep a provider's offers past their expiry, or to stop doing so
+// if pinned is false. If merkleRoots is empty all of the provider's offers are affected.
+func (g *GatewayManager) PinOffers(gatewayInfo *register.GatewayRegister, providerID *nodeid.NodeID, merkleRoots []string, pinned bool) (*OfferAck, error) {
+	operation := "pin-offers"
+	if !pinned {
+		operation = "unpin-offers"
+	}
+	request, err := fcradminmessages.EncodeAdminPinOffersChallenge(providerID, merkleRoots, pinned)
+	if err != nil {
+		return nil, err
+	}
+	return g.offerOperation(operation, gatewayInfo, providerID, request, map[string]string{
+		"merkle_roots": strings.Join(merkleRoots, ","),
+	})
+}
+
+// RefreshOffers asks a gateway to fetch a provider's current offers from the provider.
+func (g *GatewayManager) RefreshOffers(gatewayInfo *register.GatewayRegister, providerID *nodeid.NodeID) (*OfferAck, error) {
+	request, err := fcradminmessages.EncodeAdminRefreshOffersChallenge(providerID)
+	if err != nil {
+		return nil, err
+	}
+	return g.offerOperation("refresh-offers", gatewayInfo, providerID, request, nil)
+}
+
+// offerOperation sends an offer challenge to a gateway and checks its acknowledgement.
+func (g *GatewayManager) offerOperation(operation string, gatewayInfo *register.GatewayRegister, providerID *nodeid.NodeID, request *fcrmessages.FCRMessage, params map[string]string) (ack *OfferAck, err error) {
+	start := time.Now()
+	ctx, span := g.tracer.Start(context.Background(), operation, tracing.Gateway(gatewayInfo.NodeID))
+	if params == nil {
+		params = make(map[string]string)
+	}
+	params["provider_id"] = providerID.ToString()
+	defer func() {
+		tracing.End(span, err)
+		g.finishOperation(operation, gatewayInfo.NodeID, params, start, err)
+	}()
+
+	response, err := g.sendAdminRequest(ctx, operation, gatewayInfo, request, fcradminmessages.AdminOfferAckType)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := fcradminmessages.DecodeAdminOfferAck(response)
+	if err != nil {
+		g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+		return nil, err
+	}
+	if msg.Challenge != request.MessageType || msg.ProviderID.ToString() != providerID.ToString() {
+		g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+		return nil, fmt.Errorf("Gateway %s acknowledged a different %s request", gatewayInfo.NodeID, operation)
+	}
+	if !msg.Accepted {
+		reason := msg.Reason
+		if reason == "" {
+			reason = "no reason given"
+		}
+		return nil, fmt.Errorf("Gateway %s refused %s: %s", gatewayInfo.NodeID, operation, reason)
+	}
+	return &OfferAck{AdminOfferAck: *msg, Signature: response.Signature}, nil
+}
+
+// offerPageSize is the number of offers requested at a time when streaming a gateway's offers.
+const offerPageSize = 200
+
+// StreamOffers reads the offers in a gateway's cache a page at a time, starting from cursor,
+// which is empty to start at the beginning. Each page is passed to fn with the cursor of the
+// page after it, which is empty for the last page. Streaming stops if fn returns an error.
+// The requests are traced, audited and counted in the metrics under operation.
+func (g *GatewayManager) StreamOffers(operation string, gatewayInfo *register.GatewayRegister, cursor string, fn func(offers []fcradminmessages.Offer, next string) error) (err error) {
+	start := time.Now()
+	count := 0
+	ctx, span := g.tracer.Start(context.Background(), operation, tracing.Gateway(gatewayInfo.NodeID))
+	defer func() {
+		tracing.End(span, err)
+		g.finishOperation(operation, gatewayInfo.NodeID, map[string]string{
+			"cursor": cursor,
+			"count":  fmt.Sprintf("%d", count),
+		}, start, err)
+	}()
+
+	next := cursor
+	for {
+		request, err := fcradminmessages.EncodeAdminListOffersChallenge(next, offerPageSize)
+		if err != nil {
+			return err
+		}
+		response, err := g.sendAdminRequest(ctx, operation, gatewayInfo, request, fcradminmessages.AdminListOffersResponseType)
+		if err != nil {
+			return err
+		}
+		offers, pageNext, err := fcradminmessages.DecodeAdminListOffersResponse(response)
+		if err != nil {
+			g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+			return err
+		}
+		if len(offers) > offerPageSize || (pageNext != "" && pageNext == next) {
+			g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+			return fmt.Errorf("Gateway %s returned an invalid page of offers", gatewayInfo.NodeID)
+		}
+		if err = fn(offers, pageNext); err != nil {
+			return err
+		}
+		count += len(offers)
+		if pageNext == "" {
+			return nil
+		}
+		next = pageNext
+	}
+}
+
+// NewOfferVerifier creates an offer verifier from the provider keys currently in the register.
+func (g *GatewayManager) NewOfferVerifier() (*offerverify.Verifier, error) {
+	providers, err := register.GetRegisteredProviders(g.settings.RegisterURL())
+	if err != nil {
+		return nil, adminerrors.New(adminerrors.ErrRegisterFailure, "", fmt.Errorf("Unable to read providers from register: %s", err))
+	}
+	return offerverify.New(providers), nil
+}
+
+// VerifyOffers reads every offer in a gateway's cache and verifies it against the register's
+// provider keys, passing each result to fn. It stops if fn returns an error.
+func (g *GatewayManager) VerifyOffers(gatewayInfo *register.GatewayRegister, fn func(result offerverify.Result) error) (err error) {
+	checked, failed := 0, 0
+	defer func() {
+		g.recordAudit(gatewayInfo.NodeID, "verify-offers", map[string]string{
+			"checked": fmt.Sprintf("%d", checked),
+			"failed":  fmt.Sprintf("%d", failed),
+		}, err)
+	}()
+
+	verifier, err := g.NewOfferVerifier()
+	if err != nil {
+		return err
+	}
+	return g.StreamOffers("verify-offers", gatewayInfo, "", func(offers []fcradminmessages.Offer, next string) error {
+		for i := range offers {
+			result := verifier.Verify(&offers[i])
+			checked++
+			if !result.Valid() {
+				failed++
+				log.Warn("Gateway %s is serving an offer from provider %s which failed verification: %s", gatewayInfo.NodeID, offers[i].ProviderID.ToString(), result.Status)
+			}
+			if err := fn(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CheckOfferConsistency reads the offers of every managed gateway matching the selector and
+// compares them. With a sample rate below 1 only that fraction of offers is compared.
+func (g *GatewayManager) CheckOfferConsistency(sel string, sampleRate float64) (report *consistency.Report, err error) {
+	defer func() {
+		g.recordAudit("", "check-offer-consistency", map[string]string{
+			"selector":    sel,
+			"sample_rate": fmt.Sprintf("%g", sampleRate),
+		}, err)
+	}()
+
+	checker, err := consistency.NewChecker(sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	gateways, err := g.SelectGateways(sel)
+	if err != nil {
+		return nil, err
+	}
+	if len(gateways) < 2 {
+		return nil, fmt.Errorf("Selector %q matches %d gateways: at least two are needed to compare offers", sel, len(gateways))
+	}
+	for i := range gateways {
+		nodeID := gateways[i].NodeID()
+		checker.AddGateway(nodeID)
+		err := g.StreamOffers("check-offer-consistency", &gateways[i].Register, "", func(offers []fcradminmessages.Offer, next string) error {
+			for j := range offers {
+				checker.Add(nodeID, &offers[j])
+			}
+			return nil
+		})
+		if err != nil {
+			log.Warn("Unable to read offers from gateway %s: %s", nodeID, err)
+			checker.Failed(nodeID, err)
+		}
+	}
+	return checker.Report(), nil
+}
@@ -0,0 +1,64 @@
+package control
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/tracing"
+)
+
+const (
+	registerGatewayPath = "/registers/gateway"
+	registerHTTPTimeout = 10 * time.Second
+)
+
+// registerGateway adds the gateway to the register service. This does the same as
+// register.GatewayRegister.RegisterGateway, but propagates the trace context and
+// checks the response status.
+func (g *GatewayManager) registerGateway(ctx context.Context, gatewayInfo *register.GatewayRegister) (err error) {
+	ctx, span := g.tracer.Start(ctx, "register-gateway", tracing.Gateway(gatewayInfo.NodeID))
+	defer func() { tracing.End(span, err) }()
+
+	body, err := json.Marshal(gatewayInfo)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", g.settings.RegisterURL()+registerGatewayPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	g.tracer.Inject(ctx, req.Header)
+
+	resp, err := g.registerClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Register returned status %d when registering gateway %s", resp.StatusCode, gatewayInfo.NodeID)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package control
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/reputationfile"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/tracing"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+// GetClientReputation asks a gateway for a client's reputation. exists is false if the
+// gateway holds no reputation for the client. The last change time and history are only
+// set if the gateway records them.
+func (g *GatewayManager) GetClientReputation(gatewayInfo *register.GatewayRegister, clientID *nodeid.NodeID) (rep *fcradminmessages.ClientReputation, exists bool, err error) {
+	const operation = "get-client-reputation"
+	start := time.Now()
+	ctx, span := g.tracer.Start(context.Background(), operation, tracing.Gateway(gatewayInfo.NodeID))
+	defer func() {
+		tracing.End(span, err)
+		g.finishOperation(operation, gatewayInfo.NodeID, map[string]string{"client_id": clientID.ToString()}, start, err)
+	}()
+
+	request, err := fcrmessages.EncodeAdminGetReputationChallenge(clientID)
+	if err != nil {
+		return nil, false, err
+	}
+	response, err := g.sendAdminRequest(ctx, operation, gatewayInfo, request, fcrmessages.AdminGetReputationResponseType)
+	if err != nil {
+		return nil, false, err
+	}
+	respClientID, reputation, exists, err := fcrmessages.DecodeAdminGetReputationResponse(response)
+	if err != nil {
+		g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+		return nil, false, err
+	}
+	if respClientID.ToString() != clientID.ToString() {
+		g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+		return nil, false, fmt.Errorf("Gateway returned reputation of client %s, expected %s", respClientID.ToString(), clientID.ToString())
+	}
+	ext, err := fcradminmessages.DecodeAdminGetReputationResponseExtension(response)
+	if err != nil {
+		g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+		return nil, false, err
+	}
+	if !exists {
+		return nil, false, nil
+	}
+	return &fcradminmessages.ClientReputation{
+		ClientID:    *respClientID,
+		Reputation:  reputation,
+		LastChanged: ext.LastChanged,
+		History:     ext.History,
+	}, true, nil
+}
+
+// ListClientReputations reads a page of the client reputations held by a gateway. Gateways
+// which do not filter by value or change time are filtered here, so a page may hold fewer
+// entries than the limit even when there are more to come.
+func (g *GatewayManager) ListClientReputations(gatewayInfo *register.GatewayRegister, filter *fcradminmessages.ReputationFilter) (page *fcradminmessages.AdminListReputationsResponse, err error) {
+	const operation = "list-client-reputations"
+	start := time.Now()
+	ctx, span := g.tracer.Start(context.Background(), operation, tracing.Gateway(gatewayInfo.NodeID))
+	defer func() {
+		tracing.End(span, err)
+		g.finishOperation(operation, gatewayInfo.NodeID, map[string]string{"cursor": filter.Cursor}, start, err)
+	}()
+
+	page, err = g.listClientReputations(ctx, operation, gatewayInfo, filter)
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]fcradminmessages.ClientReputation, 0, len(page.Reputations))
+	for _, r := range page.Reputations {
+		if !filter.Matches(&r) {
+			continue
+		}
+		if !filter.IncludeHistory {
+			r.History = nil
+		}
+		selected = append(selected, r)
+	}
+	page.Reputations = selected
+	return page, nil
+}
+
+// SetClientReputation asks a gateway to set a client's reputation.
+func (g *GatewayManager) SetClientReputation(gatewayInfo *register.GatewayRegister, clientID *nodeid.NodeID, reputation int64) (err error) {
+	const operation = "set-client-reputation"
+	start := time.Now()
+	ctx, span := g.tracer.Start(context.Background(), operation, tracing.Gateway(gatewayInfo.NodeID))
+	defer func() {
+		tracing.End(span, err)
+		g.finishOperation(operation, gatewayInfo.NodeID, map[string]string{
+			"client_id":  clientID.ToString(),
+			"reputation": fmt.Sprintf("%d", reputation),
+		}, start, err)
+	}()
+
+	request, err := fcrmessages.EncodeAdminSetReputationChallenge(clientID, reputation)
+	if err != nil {
+		return err
+	}
+	response, err := g.sendAdminRequest(ctx, operation, gatewayInfo, request, fcrmessages.AdminSetReputationResponseType)
+	if err != nil {
+		return err
+	}
+	respClientID, respReputation, exists, err := fcrmessages.DecodeAdminSetReputationResponse(response)
+	if err != nil {
+		g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+		return err
+	}
+	if respClientID.ToString() != clientID.ToString() || !exists || respReputation != reputation {
+		return fmt.Errorf("Gateway did not set reputation of client %s to %d", clientID.ToString(), reputation)
+	}
+	return nil
+}
+
+// reputationPageSize is the number of reputations requested at a time when exporting.
+const reputationPageSize = 500
+
+// ExportClientReputations reads every client reputation held by a gateway.
+func (g *GatewayManager) ExportClientReputations(gatewayInfo *register.GatewayRegister) (records []reputationfile.Record, err error) {
+	const operation = "export-client-reputations"
+	start := time.Now()
+	ctx, span := g.tracer.Start(context.Background(), operation, tracing.Gateway(gatewayInfo.NodeID))
+	defer func() {
+		tracing.End(span, err)
+		g.finishOperation(operation, gatewayInfo.NodeID, map[string]string{"count": fmt.Sprintf("%d", len(records))}, start, err)
+	}()
+
+	records = make([]reputationfile.Record, 0)
+	filter := fcradminmessages.ReputationFilter{Limit: reputationPageSize}
+	for {
+		page, err := g.listClientReputations(ctx, operation, gatewayInfo, &filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range page.Reputations {
+			records = append(records, reputationfile.Record{ClientID: r.ClientID.ToString(), Reputation: r.Reputation})
+		}
+		if page.NextCursor == "" {
+			return records, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// ImportClientReputations sets the reputation of each valid row on a gateway. The rows are
+// returned with Err set for those which were invalid or could not be set. If dryRun is true
+// the rows are only validated.
+func (g *GatewayManager) ImportClientReputations(gatewayInfo *register.GatewayRegister, rows []reputationfile.Row, dryRun bool) []reputationfile.Row {
+	results := make([]reputationfile.Row, len(rows))
+	copy(results, rows)
+	for i := range results {
+		if results[i].Err != nil || dryRun {
+			continue
+		}
+		clientID, err := nodeid.NewNodeIDFromString(results[i].ClientID)
+		if err == nil {
+			err = g.SetClientReputation(gatewayInfo, clientID, results[i].Reputation)
+		}
+		results[i].Err = err
+	}
+	return results
+}
+
+// listClientReputations reads one page of client reputations from a gateway, checking that
+// the page is no longer than requested, is in client ID order and moves the cursor on.
+func (g *GatewayManager) listClientReputations(ctx context.Context, operation string, gatewayInfo *register.GatewayRegister, filter *fcradminmessages.ReputationFilter) (*fcradminmessages.AdminListReputationsResponse, error) {
+	request, err := fcradminmessages.EncodeAdminListReputationsChallenge(filter)
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.sendAdminRequest(ctx, operation, gatewayInfo, request, fcradminmessages.AdminListReputationsResponseType)
+	if err != nil {
+		return nil, err
+	}
+	reputations, next, err := fcradminmessages.DecodeAdminListReputationsResponse(response)
+	if err != nil {
+		g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+		return nil, err
+	}
+	valid := filter.Limit <= 0 || len(reputations) <= int(filter.Limit)
+	valid = valid && !(next != "" && strings.EqualFold(next, filter.Cursor))
+	for i := 1; valid && i < len(reputations); i++ {
+		valid = strings.ToLower(reputations[i-1].ClientID.ToString()) < strings.ToLower(reputations[i].ClientID.ToString())
+	}
+	if !valid {
+		g.metrics.ProtocolError(operation, gatewayInfo.NodeID)
+		return nil, fmt.Errorf("Gateway %s returned an invalid page of reputations", gatewayInfo.NodeID)
+	}
+	return &fcradminmessages.AdminListReputationsResponse{Reputations: reputations, NextCursor: next}, nil
+}
@@ -0,0 +1,134 @@
+// Package dialer provides dialers which reach gateways through a SOCKS5 proxy, an HTTP
+// CONNECT proxy or an SSH jump host.
+package dialer
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+)
+
+// Direct returns a dialer which connects to gateways directly.
+func Direct() settings.Dialer {
+	return &net.Dialer{Timeout: settings.DefaultTCPDialTimeout}
+}
+
+// Resolving connects to gateways directly, looking up host names with a resolver and
+// trying each address in turn.
+type Resolving struct {
+	// Resolver looks up host names. Nil means the system resolver.
+	Resolver  settings.Resolver
+	KeepAlive time.Duration
+}
+
+// DialContext looks up the host of address and connects to the first address which accepts
+// the connection.
+func (d *Resolving) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	nd := net.Dialer{Timeout: settings.DefaultTCPDialTimeout, KeepAlive: d.KeepAlive}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || d.Resolver == nil || IsIPLiteral(host) {
+		return nd.DialContext(ctx, network, address)
+	}
+	addrs, err := d.Resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to look up %s: %s", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("No addresses found for %s", host)
+	}
+	for _, addr := range addrs {
+		var conn net.Conn
+		conn, err = nd.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// IsIPLiteral returns true for IPv4 and IPv6 addresses, including IPv6 addresses with a zone.
+func IsIPLiteral(host string) bool {
+	if i := strings.LastIndex(host, "%"); i > 0 && strings.Contains(host, ":") {
+		host = host[:i]
+	}
+	return net.ParseIP(host) != nil
+}
+
+// Parse creates a dialer from a proxy URL, which is one of:
+//
+//	socks5://[user:password@]host:port
+//	http://[user:password@]host:port or https://... for an HTTP CONNECT proxy
+//	ssh://user@host[:port]?key=/path/to/key[&known_hosts=/path/to/known_hosts]
+func Parse(proxyURL string) (settings.Dialer, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid proxy URL: %s", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("Proxy URL %s has no host", proxyURL)
+	}
+	switch u.Scheme {
+	case "socks5":
+		d := SOCKS5{Address: u.Host}
+		if u.User != nil {
+			d.Username = u.User.Username()
+			d.Password, _ = u.User.Password()
+		}
+		return &d, nil
+	case "http", "https":
+		return &HTTPConnect{ProxyURL: u}, nil
+	case "ssh":
+		if u.User == nil || u.User.Username() == "" {
+			return nil, fmt.Errorf("SSH proxy URL %s has no user", proxyURL)
+		}
+		return NewSSHJump(SSHConfig{
+			Address:        u.Host,
+			User:           u.User.Username(),
+			KeyFile:        u.Query().Get("key"),
+			KnownHostsFile: u.Query().Get("known_hosts"),
+		})
+	}
+	return nil, fmt.Errorf("Unsupported proxy scheme: %s", u.Scheme)
+}
+
+// dialProxy connects to a proxy, applying the dial timeout if ctx has no deadline. Without
+// a forward dialer the proxy is dialled directly, looking up its host name with resolver.
+func dialProxy(ctx context.Context, forward settings.Dialer, resolver settings.Resolver, address string) (net.Conn, error) {
+	if forward == nil {
+		forward = &Resolving{Resolver: resolver}
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, settings.DefaultTCPDialTimeout)
+		defer cancel()
+	}
+	return forward.DialContext(ctx, "tcp", address)
+}
+
+// handshakeDeadline is the deadline for a proxy handshake.
+func handshakeDeadline(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(settings.DefaultTCPDialTimeout)
+}
@@ -0,0 +1,115 @@
+package dialer
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+)
+
+// HTTPConnect connects to gateways through an HTTP proxy using the CONNECT method. The
+// proxy URL may have an https scheme, in which case the proxy is connected to over TLS,
+// and may carry a user name and password for basic proxy authentication.
+type HTTPConnect struct {
+	ProxyURL *url.URL
+	// TLSConfig is used to connect to an https proxy. Nil means the default configuration.
+	TLSConfig *tls.Config
+	// Forward dials the proxy. Nil means the proxy is dialled directly.
+	Forward settings.Dialer
+
+	resolver settings.Resolver
+}
+
+// UseResolver sets the resolver used to look up the proxy's host name when it is dialled
+// directly.
+func (d *HTTPConnect) UseResolver(r settings.Resolver) {
+	d.resolver = r
+}
+
+// DialContext connects to address through the proxy.
+func (d *HTTPConnect) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, fmt.Errorf("HTTP proxy does not support network %s", network)
+	}
+	proxyAddr := d.ProxyURL.Host
+	if d.ProxyURL.Port() == "" {
+		port := "80"
+		if d.ProxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(d.ProxyURL.Hostname(), port)
+	}
+	conn, err := dialProxy(ctx, d.Forward, d.resolver, proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(handshakeDeadline(ctx))
+	if d.ProxyURL.Scheme == "https" {
+		config := d.TLSConfig
+		if config == nil {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config = config.Clone()
+			config.ServerName = d.ProxyURL.Hostname()
+		}
+		conn = tls.Client(conn, config)
+	}
+	proxied, err := d.connect(conn, address)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("HTTP proxy %s: %s", proxyAddr, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return proxied, nil
+}
+
+func (d *HTTPConnect) connect(conn net.Conn, address string) (net.Conn, error) {
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if d.ProxyURL.User != nil {
+		password, _ := d.ProxyURL.User.Password()
+		credentials := d.ProxyURL.User.Username() + ":" + password
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Connect to %s failed: %s", address, resp.Status)
+	}
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn returns data read past the end of the proxy's response before reading
+// from the connection.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
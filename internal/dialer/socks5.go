@@ -0,0 +1,182 @@
+package dialer
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+)
+
+const (
+	socks5Version         = 5
+	socks5AuthNone        = 0
+	socks5AuthPassword    = 2
+	socks5AuthNoAccept    = 0xff
+	socks5PasswordVersion = 1
+	socks5Connect         = 1
+	socks5AddrIPv4        = 1
+	socks5AddrDomain      = 3
+	socks5AddrIPv6        = 4
+)
+
+var socks5Replies = map[byte]string{
+	1: "general failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// SOCKS5 connects to gateways through a SOCKS5 proxy (RFC 1928), with optional
+// username and password authentication (RFC 1929). Gateway host names are resolved
+// by the proxy.
+type SOCKS5 struct {
+	Address  string
+	Username string
+	Password string
+	// Forward dials the proxy. Nil means the proxy is dialled directly.
+	Forward settings.Dialer
+
+	resolver settings.Resolver
+}
+
+// UseResolver sets the resolver used to look up the proxy's host name when it is dialled
+// directly.
+func (d *SOCKS5) UseResolver(r settings.Resolver) {
+	d.resolver = r
+}
+
+// DialContext connects to address through the proxy.
+func (d *SOCKS5) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, fmt.Errorf("SOCKS5 proxy does not support network %s", network)
+	}
+	conn, err := dialProxy(ctx, d.Forward, d.resolver, d.Address)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(handshakeDeadline(ctx))
+	err = d.handshake(conn, address)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("SOCKS5 proxy %s: %s", d.Address, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func (d *SOCKS5) handshake(conn net.Conn, address string) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("Invalid port %s", portStr)
+	}
+
+	methods := []byte{socks5AuthNone}
+	if d.Username != "" {
+		methods = []byte{socks5AuthPassword}
+	}
+	if _, err = conn.Write(append([]byte{socks5Version, byte(len(methods))}, methods...)); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err = io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != socks5Version {
+		return fmt.Errorf("Unexpected SOCKS version %d", reply[0])
+	}
+	switch reply[1] {
+	case socks5AuthNone:
+	case socks5AuthPassword:
+		if err = d.authenticate(conn); err != nil {
+			return err
+		}
+	case socks5AuthNoAccept:
+		return errors.New("No acceptable authentication method")
+	default:
+		return fmt.Errorf("Unexpected authentication method %d", reply[1])
+	}
+
+	request := []byte{socks5Version, socks5Connect, 0}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			request = append(append(request, socks5AddrIPv4), ip4...)
+		} else {
+			request = append(append(request, socks5AddrIPv6), ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return fmt.Errorf("Host name too long: %s", host)
+		}
+		request = append(append(request, socks5AddrDomain, byte(len(host))), host...)
+	}
+	request = append(request, byte(port>>8), byte(port))
+	if _, err = conn.Write(request); err != nil {
+		return err
+	}
+
+	header := make([]byte, 4)
+	if _, err = io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[1] != 0 {
+		if msg, ok := socks5Replies[header[1]]; ok {
+			return fmt.Errorf("Connect to %s failed: %s", address, msg)
+		}
+		return fmt.Errorf("Connect to %s failed: reply code %d", address, header[1])
+	}
+	// Discard the bound address and port.
+	var addrLen int
+	switch header[3] {
+	case socks5AddrIPv4:
+		addrLen = net.IPv4len
+	case socks5AddrIPv6:
+		addrLen = net.IPv6len
+	case socks5AddrDomain:
+		length := make([]byte, 1)
+		if _, err = io.ReadFull(conn, length); err != nil {
+			return err
+		}
+		addrLen = int(length[0])
+	default:
+		return fmt.Errorf("Unexpected address type %d", header[3])
+	}
+	bound := make([]byte, addrLen+2)
+	_, err = io.ReadFull(conn, bound)
+	return err
+}
+
+func (d *SOCKS5) authenticate(conn net.Conn) error {
+	if len(d.Username) > 255 || len(d.Password) > 255 {
+		return errors.New("Username or password too long")
+	}
+	request := []byte{socks5PasswordVersion, byte(len(d.Username))}
+	request = append(request, d.Username...)
+	request = append(request, byte(len(d.Password)))
+	request = append(request, d.Password...)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[1] != 0 {
+		return errors.New("Authentication failed")
+	}
+	return nil
+}
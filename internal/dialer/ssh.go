@@ -0,0 +1,318 @@
+package dialer
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+)
+
+const defaultSSHPort = "22"
+
+// SSHConfig configures an SSH jump host.
+type SSHConfig struct {
+	// Address is the host and optional port of the jump host.
+	Address string
+	User    string
+	// KeyFile is the unencrypted private key used to log in.
+	KeyFile string
+	// KnownHostsFile holds the jump host's key. Empty means ~/.ssh/known_hosts.
+	KnownHostsFile string
+	// Forward dials the jump host. Nil means the jump host is dialled directly.
+	Forward settings.Dialer
+}
+
+// SSHJump connects to gateways through an SSH jump host, tunnelling each connection
+// over a single SSH connection which is re-established if it drops.
+type SSHJump struct {
+	address string
+	config  *ssh.ClientConfig
+	forward settings.Dialer
+
+	lock     sync.Mutex
+	client   *ssh.Client
+	resolver settings.Resolver
+}
+
+// NewSSHJump creates a dialer for an SSH jump host. The jump host's key must be in the
+// known hosts file.
+func NewSSHJump(conf SSHConfig) (*SSHJump, error) {
+	if conf.KeyFile == "" {
+		return nil, errors.New("SSH jump host key file not set")
+	}
+	pemBytes, err := ioutil.ReadFile(conf.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read SSH key: %s", err)
+	}
+	signer, err := ssh.ParsePrivateKey(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse SSH key %s: %s", conf.KeyFile, err)
+	}
+	knownHostsFile := conf.KnownHostsFile
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to find known hosts file: %s", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read known hosts: %s", err)
+	}
+	address := conf.Address
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultSSHPort)
+	}
+	return &SSHJump{
+		address: address,
+		forward: conf.Forward,
+		config: &ssh.ClientConfig{
+			User:            conf.User,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         settings.DefaultTCPDialTimeout,
+		},
+	}, nil
+}
+
+// DialContext connects to address through the jump host. If the tunnel can not be opened
+// the SSH connection is re-established once.
+func (d *SSHJump) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	client, err := d.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.Dial(network, address)
+	if err != nil {
+		log.Warn("Unable to tunnel to %s through SSH jump host %s, reconnecting: %s", address, d.address, err)
+		d.dropClient(client)
+		client, err = d.getClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		conn, err = client.Dial(network, address)
+		if err != nil {
+			return nil, fmt.Errorf("SSH jump host %s: %s", d.address, err)
+		}
+	}
+	return newDeadlineConn(conn), nil
+}
+
+// UseResolver sets the resolver used to look up the jump host's name when it is dialled
+// directly.
+func (d *SSHJump) UseResolver(r settings.Resolver) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.resolver = r
+}
+
+// Close closes the SSH connection to the jump host.
+func (d *SSHJump) Close() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.client == nil {
+		return nil
+	}
+	err := d.client.Close()
+	d.client = nil
+	return err
+}
+
+func (d *SSHJump) getClient(ctx context.Context) (*ssh.Client, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.client != nil {
+		return d.client, nil
+	}
+	conn, err := dialProxy(ctx, d.forward, d.resolver, d.address)
+	if err != nil {
+		return nil, fmt.Errorf("SSH jump host %s: %s", d.address, err)
+	}
+	conn.SetDeadline(handshakeDeadline(ctx))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, d.address, d.config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("SSH jump host %s: %s", d.address, err)
+	}
+	conn.SetDeadline(time.Time{})
+	d.client = ssh.NewClient(sshConn, chans, reqs)
+	return d.client, nil
+}
+
+func (d *SSHJump) dropClient(client *ssh.Client) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.client == client {
+		d.client.Close()
+		d.client = nil
+	}
+}
+
+// deadlineConn adds deadlines to a tunnelled connection, which does not support them.
+// Reads are done in the background so a read can time out and be retried without losing
+// data. A write still running at the deadline closes the connection.
+type deadlineConn struct {
+	net.Conn
+	lock      sync.Mutex
+	deadline  time.Time
+	timedOut  bool
+	readLock  sync.Mutex
+	chunks    chan readResult
+	pending   []byte
+	readErr   error
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+type readResult struct {
+	data []byte
+	err  error
+}
+
+func newDeadlineConn(conn net.Conn) *deadlineConn {
+	c := deadlineConn{
+		Conn:   conn,
+		chunks: make(chan readResult),
+		closed: make(chan struct{}),
+	}
+	go c.pump()
+	return &c
+}
+
+// pump reads from the tunnel until it fails or the connection is closed.
+func (c *deadlineConn) pump() {
+	for {
+		buf := make([]byte, 32*1024)
+		n, err := c.Conn.Read(buf)
+		select {
+		case c.chunks <- readResult{buf[:n], err}:
+		case <-c.closed:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (c *deadlineConn) Read(b []byte) (int, error) {
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+	if len(c.pending) > 0 {
+		n := copy(b, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+	if c.readErr != nil {
+		return 0, c.readErr
+	}
+
+	c.lock.Lock()
+	deadline := c.deadline
+	c.lock.Unlock()
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return 0, timeoutError{}
+		}
+		timer := time.NewTimer(remaining)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case r := <-c.chunks:
+		n := copy(b, r.data)
+		c.pending = r.data[n:]
+		c.readErr = r.err
+		if n == 0 {
+			return 0, r.err
+		}
+		return n, nil
+	case <-timeout:
+		return 0, timeoutError{}
+	case <-c.closed:
+		return 0, errClosed
+	}
+}
+
+// Write writes to the tunnel, closing the connection if the write is still running at
+// the deadline.
+func (c *deadlineConn) Write(b []byte) (int, error) {
+	c.lock.Lock()
+	deadline, timedOut := c.deadline, c.timedOut
+	c.lock.Unlock()
+	if timedOut {
+		return 0, timeoutError{}
+	}
+	if !deadline.IsZero() {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return 0, timeoutError{}
+		}
+		timer := time.AfterFunc(remaining, func() {
+			c.lock.Lock()
+			c.timedOut = true
+			c.lock.Unlock()
+			c.Close()
+		})
+		defer timer.Stop()
+	}
+	n, err := c.Conn.Write(b)
+	if err != nil {
+		c.lock.Lock()
+		if c.timedOut {
+			err = timeoutError{}
+		}
+		c.lock.Unlock()
+	}
+	return n, err
+}
+
+func (c *deadlineConn) Close() error {
+	err := errClosed
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		err = c.Conn.Close()
+	})
+	return err
+}
+
+func (c *deadlineConn) SetDeadline(t time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.deadline = t
+	return nil
+}
+
+func (c *deadlineConn) SetReadDeadline(t time.Time) error {
+	return c.SetDeadline(t)
+}
+
+func (c *deadlineConn) SetWriteDeadline(t time.Time) error {
+	return c.SetDeadline(t)
+}
+
+var errClosed = errors.New("Use of closed SSH tunnel")
+
+// timeoutError is returned once a deadline has passed.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,118 @@
+package fleetplan
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/selector"
+)
+
+// Config is the desired state of a fleet of gateways.
+type Config struct {
+	Gateways []GatewaySpec `yaml:"gateways"`
+}
+
+// GatewaySpec is the desired state of one gateway. Nil pointer fields are left as they are.
+type GatewaySpec struct {
+	NodeID      string `yaml:"node_id"`
+	Initialized *bool  `yaml:"initialized,omitempty"`
+	// KeyVersion is the version of the gateway's private key. A gateway whose key has a
+	// different version is given a new key.
+	KeyVersion uint32            `yaml:"key_version,omitempty"`
+	Blocked    *bool             `yaml:"blocked,omitempty"`
+	Tags       map[string]string `yaml:"tags,omitempty"`
+	// Reputation overrides, keyed by client node ID.
+	Reputation map[string]int64 `yaml:"reputation,omitempty"`
+
+	// Registration details, used when initialising a gateway which is not yet in the register.
+	Address             string `yaml:"address,omitempty"`
+	RootSigningKey      string `yaml:"root_signing_key,omitempty"`
+	RegionCode          string `yaml:"region_code,omitempty"`
+	NetworkInfoGateway  string `yaml:"network_info_gateway,omitempty"`
+	NetworkInfoProvider string `yaml:"network_info_provider,omitempty"`
+	NetworkInfoClient   string `yaml:"network_info_client,omitempty"`
+	NetworkInfoAdmin    string `yaml:"network_info_admin,omitempty"`
+}
+
+// Load reads and validates a desired state file.
+func Load(path string) (*Config, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(raw)
+}
+
+// Parse parses and validates a desired state document.
+func Parse(raw []byte) (*Config, error) {
+	var c Config
+	if err := yaml.UnmarshalStrict(raw, &c); err != nil {
+		return nil, fmt.Errorf("Invalid fleet configuration: %s", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate checks the configuration for errors.
+func (c *Config) Validate() error {
+	seen := make(map[string]bool, len(c.Gateways))
+	for i, gw := range c.Gateways {
+		id, err := nodeid.NewNodeIDFromString(gw.NodeID)
+		if err != nil {
+			return fmt.Errorf("Gateway %d: invalid node_id %q: %s", i+1, gw.NodeID, err)
+		}
+		key := strings.ToLower(id.ToString())
+		if seen[key] {
+			return fmt.Errorf("Gateway %s is listed more than once", gw.NodeID)
+		}
+		seen[key] = true
+		for k, v := range gw.Tags {
+			if err := selector.ValidateTag(k, v); err != nil {
+				return fmt.Errorf("Gateway %s: %s", gw.NodeID, err)
+			}
+		}
+		for clientID := range gw.Reputation {
+			if _, err := nodeid.NewNodeIDFromString(clientID); err != nil {
+				return fmt.Errorf("Gateway %s: invalid client ID %q in reputation: %s", gw.NodeID, clientID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Registration returns the register entry for a gateway which is to be initialised,
+// without its signing key.
+func (s *GatewaySpec) Registration() *register.GatewayRegister {
+	return &register.GatewayRegister{
+		NodeID:              s.NodeID,
+		Address:             s.Address,
+		RootSigningKey:      s.RootSigningKey,
+		RegionCode:          s.RegionCode,
+		NetworkInfoGateway:  s.NetworkInfoGateway,
+		NetworkInfoProvider: s.NetworkInfoProvider,
+		NetworkInfoClient:   s.NetworkInfoClient,
+		NetworkInfoAdmin:    s.NetworkInfoAdmin,
+	}
+}
@@ -0,0 +1,232 @@
+package fleetplan
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/inventory"
+)
+
+// ActionKind is the type of change an action makes.
+type ActionKind string
+
+// Kinds of action, in the order they are applied to a gateway.
+const (
+	ActionInitialize    ActionKind = "initialize"
+	ActionAdopt         ActionKind = "adopt"
+	ActionRekey         ActionKind = "rekey"
+	ActionUnblock       ActionKind = "unblock"
+	ActionSetTags       ActionKind = "set-tags"
+	ActionSetReputation ActionKind = "set-reputation"
+	ActionBlock         ActionKind = "block"
+)
+
+// LiveState is the current state of the fleet, keyed by lower case node ID.
+type LiveState struct {
+	Registered map[string]register.GatewayRegister
+	Managed    map[string]inventory.Gateway
+	// KeyVersions holds the key version each gateway signed its status response with. Only
+	// an initialised gateway can sign a response, so a gateway which is missing either did
+	// not answer or has not been initialised.
+	KeyVersions map[string]uint32
+	// Reputation holds the reputations which could be read from each gateway, keyed by
+	// lower case client node ID. Missing values are treated as needing to be set.
+	Reputation map[string]map[string]int64
+}
+
+// Action is a single change needed to bring a gateway to its desired state.
+type Action struct {
+	Gateway  string     `json:"gateway"`
+	Kind     ActionKind `json:"kind"`
+	ClientID string     `json:"client_id,omitempty"`
+	From     string     `json:"from,omitempty"`
+	To       string     `json:"to,omitempty"`
+}
+
+// Plan is the set of actions needed to bring the fleet to its desired state.
+type Plan struct {
+	Actions  []Action `json:"actions"`
+	Warnings []string `json:"warnings,omitempty"`
+
+	specs map[string]*GatewaySpec
+	live  *LiveState
+}
+
+// Result is the outcome of applying an action.
+type Result struct {
+	Action Action
+	Err    error
+}
+
+// String describes the action for display.
+func (a Action) String() string {
+	desc := fmt.Sprintf("%s %s", a.Kind, a.Gateway)
+	if a.ClientID != "" {
+		desc += " client " + a.ClientID
+	}
+	if a.From != "" || a.To != "" {
+		desc += fmt.Sprintf(": %s -> %s", orNone(a.From), orNone(a.To))
+	}
+	return desc
+}
+
+// Spec returns the desired state of the gateway an action applies to.
+func (p *Plan) Spec(gateway string) *GatewaySpec {
+	return p.specs[strings.ToLower(gateway)]
+}
+
+// Registered returns the register entry of a gateway as it was when the plan was made.
+func (p *Plan) Registered(gateway string) (register.GatewayRegister, bool) {
+	reg, ok := p.live.Registered[strings.ToLower(gateway)]
+	return reg, ok
+}
+
+// Empty returns true if the fleet is already in its desired state.
+func (p *Plan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+// Diff compares the desired state of the fleet with its live state.
+func Diff(desired *Config, live *LiveState) *Plan {
+	p := Plan{Actions: make([]Action, 0), specs: make(map[string]*GatewaySpec, len(desired.Gateways)), live: live}
+	for i := range desired.Gateways {
+		spec := &desired.Gateways[i]
+		key := strings.ToLower(spec.NodeID)
+		p.specs[key] = spec
+		p.diffGateway(spec, live)
+	}
+	for key := range live.Managed {
+		if p.specs[key] == nil {
+			p.warn("Managed gateway %s is not in the fleet configuration", key)
+		}
+	}
+	return &p
+}
+
+func (p *Plan) diffGateway(spec *GatewaySpec, live *LiveState) {
+	key := strings.ToLower(spec.NodeID)
+	reg, registered := live.Registered[key]
+	managed, isManaged := live.Managed[key]
+	keyVersion, initialized := live.KeyVersions[key]
+	if !initialized && registered && managed.Blocked {
+		// Blocked gateways are not asked for their status, so rely on the inventory.
+		keyVersion, initialized = managed.KeyVersion, managed.KeyVersion != 0
+	}
+
+	if spec.Initialized != nil {
+		switch {
+		case *spec.Initialized && !initialized && registered:
+			// The gateway may be unreachable, or impersonated, rather than uninitialised, so
+			// it is not sent a new private key.
+			p.warn("Gateway %s is registered but did not answer a status request; it is not initialised", spec.NodeID)
+			return
+		case *spec.Initialized && !initialized:
+			if spec.NetworkInfoAdmin == "" {
+				p.warn("Gateway %s can not be initialised without network_info_admin", spec.NodeID)
+				return
+			}
+			p.add(spec.NodeID, ActionInitialize, "", "", keyVersionString(spec.KeyVersion))
+			initialized = true
+			isManaged = true
+		case !*spec.Initialized && initialized:
+			p.warn("Gateway %s is initialised; de-initialising gateways is not supported", spec.NodeID)
+		}
+	}
+	if !initialized {
+		return
+	}
+
+	if registered && !isManaged {
+		p.add(spec.NodeID, ActionAdopt, "", "", reg.NetworkInfoAdmin)
+	}
+	if registered && spec.KeyVersion != 0 && keyVersion != spec.KeyVersion {
+		p.add(spec.NodeID, ActionRekey, "", keyVersionString(keyVersion), keyVersionString(spec.KeyVersion))
+	}
+
+	blocked := managed.Blocked
+	if spec.Blocked != nil {
+		blocked = *spec.Blocked
+	}
+	if !blocked && managed.Blocked {
+		p.add(spec.NodeID, ActionUnblock, "", "", "")
+	}
+
+	if spec.Tags != nil && !equalTags(spec.Tags, managed.Tags) {
+		p.add(spec.NodeID, ActionSetTags, "", formatTags(managed.Tags), formatTags(spec.Tags))
+	}
+
+	if len(spec.Reputation) > 0 && blocked {
+		p.warn("Reputation of gateway %s is not managed while it is blocked", spec.NodeID)
+	} else {
+		clients := make([]string, 0, len(spec.Reputation))
+		for clientID := range spec.Reputation {
+			clients = append(clients, clientID)
+		}
+		sort.Strings(clients)
+		current := live.Reputation[key]
+		for _, clientID := range clients {
+			want := spec.Reputation[clientID]
+			have, known := current[strings.ToLower(clientID)]
+			if known && have == want {
+				continue
+			}
+			from := ""
+			if known {
+				from = fmt.Sprintf("%d", have)
+			}
+			p.add(spec.NodeID, ActionSetReputation, clientID, from, fmt.Sprintf("%d", want))
+		}
+	}
+
+	if blocked && !managed.Blocked {
+		p.add(spec.NodeID, ActionBlock, "", "", "")
+	}
+}
+
+func (p *Plan) add(gateway string, kind ActionKind, clientID string, from string, to string) {
+	p.Actions = append(p.Actions, Action{Gateway: gateway, Kind: kind, ClientID: clientID, From: from, To: to})
+}
+
+func (p *Plan) warn(format string, args ...interface{}) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, args...))
+}
+
+func keyVersionString(ver uint32) string {
+	if ver == 0 {
+		return ""
+	}
+	return fmt.Sprintf("key version %d", ver)
+}
+
+func equalTags(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+func formatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,150 @@
+package fleetplan
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"testing"
+
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/inventory"
+)
+
+const gw = "0a0b"
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func registered() map[string]register.GatewayRegister {
+	return map[string]register.GatewayRegister{gw: {NodeID: gw, NetworkInfoAdmin: "gw:9013"}}
+}
+
+func managed(g inventory.Gateway) map[string]inventory.Gateway {
+	g.Register = register.GatewayRegister{NodeID: gw}
+	return map[string]inventory.Gateway{gw: g}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         GatewaySpec
+		live         LiveState
+		wantActions  []string
+		wantWarnings int
+	}{
+		{
+			name:        "initialise unregistered gateway",
+			spec:        GatewaySpec{NodeID: gw, Initialized: boolPtr(true), KeyVersion: 3, NetworkInfoAdmin: "gw:9013"},
+			wantActions: []string{"initialize 0a0b: (none) -> key version 3"},
+		},
+		{
+			name:         "initialise without admin address",
+			spec:         GatewaySpec{NodeID: gw, Initialized: boolPtr(true)},
+			wantWarnings: 1,
+		},
+		{
+			name:         "registered gateway which does not answer",
+			spec:         GatewaySpec{NodeID: gw, Initialized: boolPtr(true), NetworkInfoAdmin: "gw:9013"},
+			live:         LiveState{Registered: registered()},
+			wantWarnings: 1,
+		},
+		{
+			name:         "de-initialise",
+			spec:         GatewaySpec{NodeID: gw, Initialized: boolPtr(false)},
+			live:         LiveState{Registered: registered(), Managed: managed(inventory.Gateway{}), KeyVersions: map[string]uint32{gw: 1}},
+			wantWarnings: 1,
+		},
+		{
+			name:        "adopt",
+			spec:        GatewaySpec{NodeID: gw},
+			live:        LiveState{Registered: registered(), KeyVersions: map[string]uint32{gw: 1}},
+			wantActions: []string{"adopt 0a0b: (none) -> gw:9013"},
+		},
+		{
+			name:        "adopt and rekey",
+			spec:        GatewaySpec{NodeID: gw, KeyVersion: 2},
+			live:        LiveState{Registered: registered(), KeyVersions: map[string]uint32{gw: 1}},
+			wantActions: []string{"adopt 0a0b: (none) -> gw:9013", "rekey 0a0b: key version 1 -> key version 2"},
+		},
+		{
+			name: "rekey from the gateway's key version",
+			spec: GatewaySpec{NodeID: gw, KeyVersion: 2},
+			live: LiveState{Registered: registered(), Managed: managed(inventory.Gateway{KeyVersion: 2}),
+				KeyVersions: map[string]uint32{gw: 1}},
+			wantActions: []string{"rekey 0a0b: key version 1 -> key version 2"},
+		},
+		{
+			name: "key version up to date on the gateway",
+			spec: GatewaySpec{NodeID: gw, KeyVersion: 2},
+			live: LiveState{Registered: registered(), Managed: managed(inventory.Gateway{KeyVersion: 1}),
+				KeyVersions: map[string]uint32{gw: 2}},
+		},
+		{
+			name:        "unblock from the inventory's key version",
+			spec:        GatewaySpec{NodeID: gw, Blocked: boolPtr(false), KeyVersion: 1},
+			live:        LiveState{Registered: registered(), Managed: managed(inventory.Gateway{KeyVersion: 1, Blocked: true})},
+			wantActions: []string{"unblock 0a0b"},
+		},
+		{
+			name: "set tags",
+			spec: GatewaySpec{NodeID: gw, Tags: map[string]string{"region": "eu", "tier": "core"}},
+			live: LiveState{Registered: registered(), Managed: managed(inventory.Gateway{Tags: map[string]string{"region": "us"}}),
+				KeyVersions: map[string]uint32{gw: 1}},
+			wantActions: []string{"set-tags 0a0b: region=us -> region=eu,tier=core"},
+		},
+		{
+			name: "tags up to date",
+			spec: GatewaySpec{NodeID: gw, Tags: map[string]string{"region": "eu"}},
+			live: LiveState{Registered: registered(), Managed: managed(inventory.Gateway{Tags: map[string]string{"region": "eu"}}),
+				KeyVersions: map[string]uint32{gw: 1}},
+		},
+		{
+			name: "set reputation",
+			spec: GatewaySpec{NodeID: gw, Reputation: map[string]int64{"c3": 5, "C1": 10, "c2": 20}},
+			live: LiveState{Registered: registered(), Managed: managed(inventory.Gateway{}),
+				KeyVersions: map[string]uint32{gw: 1},
+				Reputation:  map[string]map[string]int64{gw: {"c1": 10, "c2": 15}}},
+			wantActions: []string{"set-reputation 0a0b client c2: 15 -> 20", "set-reputation 0a0b client c3: (none) -> 5"},
+		},
+		{
+			name: "block after other changes",
+			spec: GatewaySpec{NodeID: gw, Blocked: boolPtr(true), Tags: map[string]string{"region": "eu"}, Reputation: map[string]int64{"c1": 1}},
+			live: LiveState{Registered: registered(), Managed: managed(inventory.Gateway{}),
+				KeyVersions: map[string]uint32{gw: 1}},
+			wantActions:  []string{"set-tags 0a0b: (none) -> region=eu", "block 0a0b"},
+			wantWarnings: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := Diff(&Config{Gateways: []GatewaySpec{test.spec}}, &test.live)
+			got := make([]string, 0, len(p.Actions))
+			for _, a := range p.Actions {
+				got = append(got, a.String())
+			}
+			if len(got) != len(test.wantActions) {
+				t.Fatalf("actions are %q, want %q", got, test.wantActions)
+			}
+			for i := range got {
+				if got[i] != test.wantActions[i] {
+					t.Fatalf("actions are %q, want %q", got, test.wantActions)
+				}
+			}
+			if len(p.Warnings) != test.wantWarnings {
+				t.Errorf("warnings are %q, want %d", p.Warnings, test.wantWarnings)
+			}
+			if p.Empty() != (len(test.wantActions) == 0) {
+				t.Errorf("Empty() = %t with actions %q", p.Empty(), got)
+			}
+		})
+	}
+}
+
+func TestDiffWarnsOfUnconfiguredGateways(t *testing.T) {
+	live := LiveState{Managed: managed(inventory.Gateway{})}
+	p := Diff(&Config{}, &live)
+	if !p.Empty() || len(p.Warnings) != 1 {
+		t.Errorf("plan has actions %v and warnings %q, want one warning", p.Actions, p.Warnings)
+	}
+}
@@ -0,0 +1,89 @@
+// Package gatewayapi parses the addresses of gateways' admin interfaces and calls their REST API.
+package gatewayapi
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/dialer"
+)
+
+// Address is a gateway host name or IP address and a port.
+type Address struct {
+	Host string
+	Port string
+}
+
+// ParseAddress parses a host name, IPv4 address, bare or bracketed IPv6 address,
+// optionally followed by a port. If no port is given defaultPort is used.
+func ParseAddress(addr string, defaultPort string) (Address, error) {
+	addr = strings.TrimSpace(addr)
+	if len(addr) == 0 {
+		return Address{}, errors.New("Error: Host name empty")
+	}
+
+	a := Address{Port: defaultPort}
+	switch {
+	case strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]"):
+		// Bracketed IPv6 without a port.
+		a.Host = addr[1 : len(addr)-1]
+	case strings.Count(addr, ":") > 1 && !strings.HasPrefix(addr, "["):
+		// Bare IPv6 address. A port can only be given with brackets.
+		a.Host = addr
+	case strings.Contains(addr, ":"):
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return Address{}, fmt.Errorf("Error: Invalid address %s: %s", addr, err)
+		}
+		a.Host = host
+		a.Port = port
+	default:
+		a.Host = addr
+	}
+
+	if strings.Contains(a.Host, ":") && !dialer.IsIPLiteral(a.Host) {
+		return Address{}, fmt.Errorf("Error: Invalid IPv6 address: %s", a.Host)
+	}
+	if !dialer.IsIPLiteral(a.Host) && !validHostName(a.Host) {
+		return Address{}, fmt.Errorf("Error: Invalid host name: %s", a.Host)
+	}
+	port, err := strconv.Atoi(a.Port)
+	if err != nil || port < 1 || port > 65535 {
+		return Address{}, fmt.Errorf("Error: Invalid port in address %s: %s", addr, a.Port)
+	}
+	return a, nil
+}
+
+// HostPort returns the address in a form suitable for net.Dial and URLs,
+// bracketing IPv6 addresses.
+func (a Address) HostPort() string {
+	return net.JoinHostPort(a.Host, a.Port)
+}
+
+// validHostName checks a host name against the RFC 1123 label rules.
+func validHostName(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if len(host) == 0 || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+			if !isAlnum && c != '-' && c != '_' {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package gatewayapi
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		name         string
+		addr         string
+		wantHost     string
+		wantPort     string
+		wantHostPort string
+		wantErr      bool
+	}{
+		{"host name", "gateway.example.com", "gateway.example.com", "9013", "gateway.example.com:9013", false},
+		{"host name and port", "gateway.example.com:8080", "gateway.example.com", "8080", "gateway.example.com:8080", false},
+		{"IPv4", "10.0.0.1", "10.0.0.1", "9013", "10.0.0.1:9013", false},
+		{"IPv4 and port", "10.0.0.1:8080", "10.0.0.1", "8080", "10.0.0.1:8080", false},
+		{"bare IPv6", "2001:db8::1", "2001:db8::1", "9013", "[2001:db8::1]:9013", false},
+		{"bracketed IPv6", "[2001:db8::1]", "2001:db8::1", "9013", "[2001:db8::1]:9013", false},
+		{"bracketed IPv6 and port", "[2001:db8::1]:8080", "2001:db8::1", "8080", "[2001:db8::1]:8080", false},
+		{"IPv6 with zone", "[fe80::1%eth0]:8080", "fe80::1%eth0", "8080", "[fe80::1%eth0]:8080", false},
+		{"surrounding space", " gateway:8080 ", "gateway", "8080", "gateway:8080", false},
+		{"empty", "", "", "", "", true},
+		{"invalid IPv6", "2001:db8::zz", "", "", "", true},
+		{"invalid host name", "gate_way!.example.com", "", "", "", true},
+		{"label starts with hyphen", "-gateway.example.com", "", "", "", true},
+		{"port not a number", "gateway:http", "", "", "", true},
+		{"port out of range", "gateway:65536", "", "", "", true},
+		{"port zero", "gateway:0", "", "", "", true},
+		{"missing port", "gateway:", "", "", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, err := ParseAddress(test.addr, "9013")
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("ParseAddress(%q) = %+v, want an error", test.addr, a)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAddress(%q) returned %v", test.addr, err)
+			}
+			if a.Host != test.wantHost || a.Port != test.wantPort {
+				t.Errorf("ParseAddress(%q) = %+v, want host %s and port %s", test.addr, a, test.wantHost, test.wantPort)
+			}
+			if got := a.HostPort(); got != test.wantHostPort {
+				t.Errorf("HostPort() = %s, want %s", got, test.wantHostPort)
+			}
+		})
+	}
+}
@@ -2,20 +2,28 @@ package gatewayapi
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"io/ioutil"
-	"net"
 	"net/http"
+	"net/url"
 
 	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 	"github.com/bitly/go-simplejson"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/dialer"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/redact"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/tracing"
 )
 
 const (
-	apiURLStart string = "http://"
-	apiURLEnd   string = "/v1"
+	apiURLScheme    string = "http"
+	apiURLSchemeTLS string = "https"
+	apiURLEnd       string = "/v1"
 
 //	apiURLEnd string = "/client/establishment"
 )
@@ -31,12 +39,18 @@ var gatewayAdminClientAPIProtocolSupported []int
 
 // Comms holds the communications specific data
 type Comms struct {
-	apiURL string
-	nodeID *nodeid.NodeID
+	apiURL   string
+	nodeID   *nodeid.NodeID
+	redactor *redact.Redactor
+	tracer   *tracing.Tracer
+	client   *http.Client
 }
 
 // NewGatewayAPIComms creates a connection with a gateway
-func NewGatewayAPIComms(host string, nodeID *nodeid.NodeID) (*Comms, error) {
+// The host may include a port; if it does not, the default gateway admin port is used.
+// Host names are looked up with resolver when the API is called.
+// If tlsConfig is not nil the API is called over HTTPS.
+func NewGatewayAPIComms(host string, nodeID *nodeid.NodeID, resolver settings.Resolver, redactor *redact.Redactor, tracer *tracing.Tracer, tlsConfig *tls.Config) (*Comms, error) {
 	// Create the constant array.
 	if gatewayAdminClientAPIProtocolSupported == nil {
 		gatewayAdminClientAPIProtocolSupported = make([]int, 1)
@@ -44,56 +58,66 @@ func NewGatewayAPIComms(host string, nodeID *nodeid.NodeID) (*Comms, error) {
 	}
 
 	// Check that the host name is valid
-	err := validateHostName(host)
+	addr, err := ParseAddress(host, settings.DefaultGatewayAdminPort)
 	if err != nil {
 		log.Error("Host name invalid: %s", err.Error())
 		return nil, err
 	}
 
+	apiURL := url.URL{Scheme: apiURLScheme, Host: addr.HostPort(), Path: apiURLEnd}
 	netComms := Comms{}
-	netComms.apiURL = apiURLStart + host + apiURLEnd
+	d := dialer.Resolving{Resolver: resolver, KeepAlive: settings.DefaultTCPKeepAlive}
+	transport := &http.Transport{DialContext: d.DialContext}
+	if tlsConfig != nil {
+		apiURL.Scheme = apiURLSchemeTLS
+		transport.TLSClientConfig = tlsConfig
+	}
+	netComms.client = &http.Client{Transport: transport}
+	netComms.apiURL = apiURL.String()
 	netComms.nodeID = nodeID
+	netComms.redactor = redactor
+	netComms.tracer = tracer
 	return &netComms, nil
 }
 
 // GatewayCall calls the Gateway's REST API
-func (n *Comms) gatewayCall(method int32, args map[string]interface{}) *simplejson.Json {
+func (n *Comms) gatewayCall(ctx context.Context, method int32, args map[string]interface{}) (*simplejson.Json, error) {
+	ctx, span := n.tracer.Start(ctx, "gateway-api-call", tracing.Gateway(n.nodeID.ToString()))
+	defer span.End()
+
 	args["protocol_version"] = int32(1)
 	args["protocol_supported"] = []int32{1}
 	args["message_type"] = method
 	args["node_id"] = n.nodeID.ToString()
-	mJSON, _ := json.Marshal(args)
-	log.Info("JSON sent: %s", string(mJSON))
+	mJSON, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("JSON sent: %s", n.redactor.JSON(mJSON))
 	contentReader := bytes.NewReader(mJSON)
-	req, _ := http.NewRequest("POST", n.apiURL, contentReader)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, errs := client.Do(req)
-	if errs != nil {
-		log.ErrorAndPanic(errs.Error())
+	req, err := http.NewRequestWithContext(ctx, "POST", n.apiURL, contentReader)
+	if err != nil {
+		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/json")
+	n.tracer.Inject(ctx, req.Header)
 
-	data, _ := ioutil.ReadAll(resp.Body)
-	log.Info("response body: %s", string(data))
-
-	js, err := simplejson.NewJson(data)
+	resp, err := n.client.Do(req)
 	if err != nil {
-		log.ErrorAndPanic("Error decoding JSON: %s", err.Error())
+		return nil, fmt.Errorf("Error calling gateway %s: %s", n.nodeID.ToString(), err)
 	}
+	defer resp.Body.Close()
 
-	return js
-}
-
-func validateHostName(host string) error {
-	if len(host) == 0 {
-		return errors.New("Error: Host name empty")
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading response from gateway %s: %s", n.nodeID.ToString(), err)
 	}
+	log.Info("response body: %s", n.redactor.JSON(data))
 
-	ra, err := net.ResolveIPAddr("ip4", host)
+	js, err := simplejson.NewJson(data)
 	if err != nil {
-		return errors.New("Error: DNS Look-up failed for host: " + host)
+		return nil, fmt.Errorf("Error decoding JSON: %s", err.Error())
 	}
-	log.Info("Resolved %s as %s\n", host, ra.String())
-	return nil
+
+	return js, nil
 }
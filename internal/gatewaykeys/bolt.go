@@ -0,0 +1,106 @@
+package gatewaykeys
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var pinsBucket = []byte("pins")
+
+// openTimeout is how long to wait for another process holding the database lock.
+const openTimeout = 5 * time.Second
+
+type boltPinStore struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens, or creates, a pin store in a BoltDB file.
+func OpenBolt(path string, readOnly bool) (PinStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout, ReadOnly: readOnly})
+	if err != nil {
+		return nil, err
+	}
+	if !readOnly {
+		err = db.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(pinsBucket)
+			return err
+		})
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &boltPinStore{db: db}, nil
+}
+
+func (s *boltPinStore) Get(nodeID string) (*Pin, error) {
+	var p *Pin
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		p, err = get(tx, nodeID)
+		return err
+	})
+	return p, err
+}
+
+func (s *boltPinStore) List() ([]Pin, error) {
+	pins := make([]Pin, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(pinsBucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			p := Pin{}
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+			pins = append(pins, p)
+			return nil
+		})
+	})
+	return pins, err
+}
+
+func (s *boltPinStore) Update(nodeID string, create bool, fn func(p *Pin) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		p, err := get(tx, nodeID)
+		if err == ErrNotPinned && create {
+			p = &Pin{NodeID: nodeID}
+		} else if err != nil {
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+		raw, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(pinsBucket).Put([]byte(key(nodeID)), raw)
+	})
+}
+
+func (s *boltPinStore) Close() error {
+	return s.db.Close()
+}
+
+func get(tx *bolt.Tx, nodeID string) (*Pin, error) {
+	b := tx.Bucket(pinsBucket)
+	if b == nil {
+		return nil, ErrNotPinned
+	}
+	raw := b.Get([]byte(key(nodeID)))
+	if raw == nil {
+		return nil, ErrNotPinned
+	}
+	p := Pin{}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,103 @@
+// Package gatewaykeys keeps the signing keys known for each gateway, by key version, so
+// responses can be verified with the key matching the version in their signature, and the
+// keys pinned for each gateway on first use, so unapproved key changes are rejected.
+package gatewaykeys
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"strings"
+	"sync"
+)
+
+// Cache holds the known signing keys of gateways. It is safe for concurrent use.
+type Cache struct {
+	lock sync.RWMutex
+	keys map[string]map[uint32]string
+}
+
+// NewCache creates an empty cache.
+func NewCache() *Cache {
+	return &Cache{keys: make(map[string]map[uint32]string)}
+}
+
+// Get returns the encoded signing key of a gateway for a key version.
+func (c *Cache) Get(nodeID string, version uint32) (string, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	key, ok := c.keys[strings.ToLower(nodeID)][version]
+	return key, ok
+}
+
+// Latest returns the latest known key version of a gateway.
+func (c *Cache) Latest(nodeID string) (uint32, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	versions, ok := c.keys[strings.ToLower(nodeID)]
+	if !ok || len(versions) == 0 {
+		return 0, false
+	}
+	latest := uint32(0)
+	for version := range versions {
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest, true
+}
+
+// Keys returns a copy of the known keys of a gateway.
+func (c *Cache) Keys(nodeID string) map[uint32]string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	keys := make(map[uint32]string)
+	for version, key := range c.keys[strings.ToLower(nodeID)] {
+		keys[version] = key
+	}
+	return keys
+}
+
+// Add records a gateway's key for a key version. It returns false, without changing the
+// cache, if a different key is already known for the version.
+func (c *Cache) Add(nodeID string, version uint32, key string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	nodeID = strings.ToLower(nodeID)
+	versions, ok := c.keys[nodeID]
+	if !ok {
+		versions = make(map[uint32]string)
+		c.keys[nodeID] = versions
+	}
+	if known, ok := versions[version]; ok && known != key {
+		return false
+	}
+	versions[version] = key
+	return true
+}
+
+// Set records a gateway's key for a key version, replacing any key already known for the
+// version, for when an operator has approved the key.
+func (c *Cache) Set(nodeID string, version uint32, key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	nodeID = strings.ToLower(nodeID)
+	if _, ok := c.keys[nodeID]; !ok {
+		c.keys[nodeID] = make(map[uint32]string)
+	}
+	c.keys[nodeID][version] = key
+}
+
+// Reset replaces all the known keys of a gateway with a single key, for when the gateway
+// has been given a new key by this client.
+func (c *Cache) Reset(nodeID string, version uint32, key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.keys[strings.ToLower(nodeID)] = map[uint32]string{version: key}
+}
+
+// Remove forgets all the keys of a gateway.
+func (c *Cache) Remove(nodeID string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.keys, strings.ToLower(nodeID))
+}
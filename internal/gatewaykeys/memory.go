@@ -0,0 +1,59 @@
+package gatewaykeys
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"sync"
+)
+
+// memoryPinStore is used when no pin file is configured.
+type memoryPinStore struct {
+	lock sync.RWMutex
+	pins map[string]Pin
+}
+
+// NewMemory creates a pin store which is not persisted.
+func NewMemory() PinStore {
+	return &memoryPinStore{pins: make(map[string]Pin)}
+}
+
+func (s *memoryPinStore) Get(nodeID string) (*Pin, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	p, ok := s.pins[key(nodeID)]
+	if !ok {
+		return nil, ErrNotPinned
+	}
+	return &p, nil
+}
+
+func (s *memoryPinStore) List() ([]Pin, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	pins := make([]Pin, 0, len(s.pins))
+	for _, p := range s.pins {
+		pins = append(pins, p)
+	}
+	return pins, nil
+}
+
+func (s *memoryPinStore) Update(nodeID string, create bool, fn func(p *Pin) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	p, ok := s.pins[key(nodeID)]
+	if !ok && !create {
+		return ErrNotPinned
+	}
+	if !ok {
+		p = Pin{NodeID: nodeID}
+	}
+	if err := fn(&p); err != nil {
+		return err
+	}
+	s.pins[key(nodeID)] = p
+	return nil
+}
+
+func (s *memoryPinStore) Close() error {
+	return nil
+}
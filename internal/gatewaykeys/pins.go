@@ -0,0 +1,114 @@
+package gatewaykeys
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNotPinned is returned when no key is pinned for a gateway.
+var ErrNotPinned = errors.New("No key pinned for gateway")
+
+// Sources of a pinned key.
+const (
+	// SourceFirstUse is a key pinned the first time it was seen, from the register or the
+	// inventory of managed gateways.
+	SourceFirstUse = "first-use"
+	// SourceInitialize is a key pinned when the gateway was initialised by this client.
+	SourceInitialize = "initialize"
+	// SourceApproved is a key change approved by an operator.
+	SourceApproved = "approved"
+)
+
+// Pin is the signing key trusted for a gateway. Responses signed with any other key are
+// rejected, and the other key is held as pending until an operator approves or revokes it.
+type Pin struct {
+	NodeID     string    `json:"node_id"`
+	SigningKey string    `json:"signing_key,omitempty"`
+	KeyVersion uint32    `json:"key_version,omitempty"`
+	Source     string    `json:"source,omitempty"`
+	PinnedAt   time.Time `json:"pinned_at,omitempty"`
+	// RevokedAt is set when the pinned key has been revoked. The next key seen for the
+	// gateway is pinned as on first use.
+	RevokedAt      time.Time `json:"revoked_at,omitempty"`
+	PendingKey     string    `json:"pending_key,omitempty"`
+	PendingVersion uint32    `json:"pending_version,omitempty"`
+	PendingAt      time.Time `json:"pending_at,omitempty"`
+}
+
+// Pinned returns true if a key is pinned.
+func (p *Pin) Pinned() bool {
+	return p.SigningKey != ""
+}
+
+// PinStore holds the pinned keys of gateways. Implementations must be safe for concurrent
+// use, and Update must apply each change atomically.
+type PinStore interface {
+	// Get returns the pin of the gateway with the given node ID, or ErrNotPinned.
+	Get(nodeID string) (*Pin, error)
+
+	// List returns all pins.
+	List() ([]Pin, error)
+
+	// Update reads, modifies and writes a pin in one step. If the gateway has no pin and
+	// create is true, fn is given a new pin with the node ID set; otherwise ErrNotPinned is
+	// returned. If fn returns an error nothing is written.
+	Update(nodeID string, create bool, fn func(p *Pin) error) error
+
+	// Close releases the store.
+	Close() error
+}
+
+// Approve pins the pending key of a gateway, returning the updated pin.
+func Approve(s PinStore, nodeID string) (*Pin, error) {
+	var approved Pin
+	err := s.Update(nodeID, false, func(p *Pin) error {
+		if p.PendingKey == "" {
+			return fmt.Errorf("No key change pending for gateway %s", nodeID)
+		}
+		p.SigningKey = p.PendingKey
+		p.KeyVersion = p.PendingVersion
+		p.Source = SourceApproved
+		p.PinnedAt = time.Now().UTC()
+		p.RevokedAt = time.Time{}
+		p.PendingKey = ""
+		p.PendingVersion = 0
+		p.PendingAt = time.Time{}
+		approved = *p
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &approved, nil
+}
+
+// Revoke removes the pinned and pending keys of a gateway. The next key seen for the
+// gateway is pinned as on first use.
+func Revoke(s PinStore, nodeID string) error {
+	return s.Update(nodeID, false, func(p *Pin) error {
+		*p = Pin{NodeID: p.NodeID, RevokedAt: time.Now().UTC()}
+		return nil
+	})
+}
+
+// key normalises a node ID for use as a store key.
+func key(nodeID string) string {
+	return strings.ToLower(nodeID)
+}
@@ -0,0 +1,113 @@
+package inventory
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var gatewaysBucket = []byte("gateways")
+
+// openTimeout is how long to wait for another process holding the database lock.
+const openTimeout = 5 * time.Second
+
+type boltStore struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens, or creates, an inventory stored in a BoltDB file.
+func OpenBolt(path string, readOnly bool) (Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout, ReadOnly: readOnly})
+	if err != nil {
+		return nil, err
+	}
+	if !readOnly {
+		err = db.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(gatewaysBucket)
+			return err
+		})
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Get(nodeID string) (*Gateway, error) {
+	var gw *Gateway
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		gw, err = get(tx, nodeID)
+		return err
+	})
+	return gw, err
+}
+
+func (s *boltStore) List() ([]Gateway, error) {
+	gateways := make([]Gateway, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(gatewaysBucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			gw := Gateway{}
+			if err := json.Unmarshal(v, &gw); err != nil {
+				return err
+			}
+			gateways = append(gateways, gw)
+			return nil
+		})
+	})
+	return gateways, err
+}
+
+func (s *boltStore) Update(nodeID string, create bool, fn func(gw *Gateway) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		gw, err := get(tx, nodeID)
+		if err == ErrNotFound && create {
+			gw = &Gateway{}
+			gw.Register.NodeID = nodeID
+		} else if err != nil {
+			return err
+		}
+		if err := fn(gw); err != nil {
+			return err
+		}
+		raw, err := json.Marshal(gw)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(gatewaysBucket).Put([]byte(key(nodeID)), raw)
+	})
+}
+
+func (s *boltStore) Delete(nodeID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(gatewaysBucket).Delete([]byte(key(nodeID)))
+	})
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+func get(tx *bolt.Tx, nodeID string) (*Gateway, error) {
+	b := tx.Bucket(gatewaysBucket)
+	if b == nil {
+		return nil, ErrNotFound
+	}
+	raw := b.Get([]byte(key(nodeID)))
+	if raw == nil {
+		return nil, ErrNotFound
+	}
+	gw := Gateway{}
+	if err := json.Unmarshal(raw, &gw); err != nil {
+		return nil, err
+	}
+	return &gw, nil
+}
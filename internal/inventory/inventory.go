@@ -0,0 +1,71 @@
+package inventory
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+)
+
+// ErrNotFound is returned when a gateway is not in the inventory.
+var ErrNotFound = errors.New("Gateway not found in inventory")
+
+// Gateway is the record held for each managed gateway.
+type Gateway struct {
+	Register      register.GatewayRegister `json:"register"`
+	KeyVersion    uint32                   `json:"key_version"`
+	InitializedAt time.Time                `json:"initialized_at,omitempty"`
+	LastStatus    string                   `json:"last_status"`
+	LastStatusAt  time.Time                `json:"last_status_at,omitempty"`
+	Tags          map[string]string        `json:"tags,omitempty"`
+	Blocked       bool                     `json:"blocked"`
+	// SigningKeys are the gateway's known signing keys, by key version.
+	SigningKeys map[uint32]string `json:"signing_keys,omitempty"`
+}
+
+// NodeID returns the gateway's node ID.
+func (gw *Gateway) NodeID() string {
+	return gw.Register.NodeID
+}
+
+// Store holds the inventory of managed gateways. Implementations must be safe for
+// concurrent use, and Update must apply each change atomically.
+type Store interface {
+	// Get returns the gateway with the given node ID, or ErrNotFound.
+	Get(nodeID string) (*Gateway, error)
+
+	// List returns all gateways in the inventory.
+	List() ([]Gateway, error)
+
+	// Update reads, modifies and writes a gateway record in one step. If the gateway
+	// is not in the inventory and create is true, fn is given a new record with the
+	// node ID set; otherwise ErrNotFound is returned. If fn returns an error nothing is written.
+	Update(nodeID string, create bool, fn func(gw *Gateway) error) error
+
+	// Delete removes a gateway from the inventory.
+	Delete(nodeID string) error
+
+	// Close releases the store.
+	Close() error
+}
+
+// key normalises a node ID for use as a store key.
+func key(nodeID string) string {
+	return strings.ToLower(nodeID)
+}
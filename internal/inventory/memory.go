@@ -0,0 +1,84 @@
+package inventory
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// memoryStore is used when no inventory file is configured. Records are copied in
+// and out so callers can not modify the stored state without Update.
+type memoryStore struct {
+	lock     sync.RWMutex
+	gateways map[string][]byte
+}
+
+// NewMemory creates an inventory which is not persisted.
+func NewMemory() Store {
+	return &memoryStore{gateways: make(map[string][]byte)}
+}
+
+func (s *memoryStore) Get(nodeID string) (*Gateway, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.get(nodeID)
+}
+
+func (s *memoryStore) List() ([]Gateway, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	gateways := make([]Gateway, 0, len(s.gateways))
+	for _, raw := range s.gateways {
+		gw := Gateway{}
+		if err := json.Unmarshal(raw, &gw); err != nil {
+			return nil, err
+		}
+		gateways = append(gateways, gw)
+	}
+	return gateways, nil
+}
+
+func (s *memoryStore) Update(nodeID string, create bool, fn func(gw *Gateway) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	gw, err := s.get(nodeID)
+	if err == ErrNotFound && create {
+		gw = &Gateway{}
+		gw.Register.NodeID = nodeID
+	} else if err != nil {
+		return err
+	}
+	if err := fn(gw); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(gw)
+	if err != nil {
+		return err
+	}
+	s.gateways[key(nodeID)] = raw
+	return nil
+}
+
+func (s *memoryStore) Delete(nodeID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.gateways, key(nodeID))
+	return nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+func (s *memoryStore) get(nodeID string) (*Gateway, error) {
+	raw, ok := s.gateways[key(nodeID)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	gw := Gateway{}
+	if err := json.Unmarshal(raw, &gw); err != nil {
+		return nil, err
+	}
+	return &gw, nil
+}
@@ -0,0 +1,191 @@
+package metrics
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+)
+
+const (
+	namespace = "fcr_gateway_admin"
+
+	// OutcomeSuccess labels an operation which completed without error.
+	OutcomeSuccess = "success"
+	// OutcomeFailure labels an operation which returned an error.
+	OutcomeFailure = "failure"
+)
+
+// Metrics holds the Prometheus collectors for admin operations and gateway connectivity.
+type Metrics struct {
+	calls             *prometheus.CounterVec
+	latency           *prometheus.HistogramVec
+	retries           *prometheus.CounterVec
+	signatureFailures *prometheus.CounterVec
+	protocolErrors    *prometheus.CounterVec
+	keyChanges        *prometheus.CounterVec
+	pooledConnections prometheus.Gauge
+	reachable         *prometheus.GaugeVec
+	server            *http.Server
+}
+
+// New creates the collectors and registers them with reg. If listenAddr is not
+// empty, the metrics are also served over HTTP at listenAddr/metrics, in which case reg
+// must also be a prometheus.Gatherer. When reg is nil a private registry is used.
+func New(reg prometheus.Registerer, listenAddr string) (*Metrics, error) {
+	var gatherer prometheus.Gatherer
+	if reg == nil {
+		registry := prometheus.NewRegistry()
+		reg = registry
+		gatherer = registry
+	} else if g, ok := reg.(prometheus.Gatherer); ok {
+		gatherer = g
+	}
+	if listenAddr != "" && gatherer == nil {
+		return nil, errors.New("Metrics registerer must also be a prometheus.Gatherer to serve metrics over HTTP")
+	}
+
+	m := Metrics{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operations_total",
+			Help:      "Number of admin operations, by operation, gateway and outcome.",
+		}, []string{"operation", "gateway", "outcome"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of admin operations, by operation, gateway and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "gateway", "outcome"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Number of times an admin operation was retried.",
+		}, []string{"operation", "gateway"}),
+		signatureFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "signature_verification_failures_total",
+			Help:      "Number of gateway responses which failed signature verification.",
+		}, []string{"operation", "gateway"}),
+		protocolErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "protocol_errors_total",
+			Help:      "Number of unexpected or undecodable gateway responses.",
+		}, []string{"operation", "gateway"}),
+		keyChanges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "unexpected_key_changes_total",
+			Help:      "Number of gateway responses signed with a key which conflicts with the gateway's known keys.",
+		}, []string{"gateway"}),
+		pooledConnections: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "pooled_connections",
+			Help:      "Number of open connections in the gateway connection pool.",
+		}),
+		reachable: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gateway_reachable",
+			Help:      "1 if the last attempt to reach the gateway succeeded, 0 otherwise.",
+		}, []string{"gateway"}),
+	}
+
+	collectors := []prometheus.Collector{m.calls, m.latency, m.retries, m.signatureFailures,
+		m.protocolErrors, m.keyChanges, m.pooledConnections, m.reachable}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if listenAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+		// Listen before returning, so a bad address or a port in use is reported to the caller.
+		l, err := net.Listen("tcp", listenAddr)
+		if err != nil {
+			for _, c := range collectors {
+				reg.Unregister(c)
+			}
+			return nil, fmt.Errorf("Unable to listen for metrics requests on %s: %s", listenAddr, err)
+		}
+		m.server = &http.Server{Addr: listenAddr, Handler: mux}
+		go func() {
+			if err := m.server.Serve(l); err != nil && err != http.ErrServerClosed {
+				log.Error("Metrics endpoint on %s stopped: %s", listenAddr, err)
+			}
+		}()
+	}
+	return &m, nil
+}
+
+// ObserveOperation records the outcome and latency of an admin operation started at start.
+func (m *Metrics) ObserveOperation(operation string, gateway string, start time.Time, err error) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeFailure
+	}
+	m.calls.WithLabelValues(operation, gateway, outcome).Inc()
+	m.latency.WithLabelValues(operation, gateway, outcome).Observe(time.Since(start).Seconds())
+}
+
+// Retry records that an operation on a gateway was retried.
+func (m *Metrics) Retry(operation string, gateway string) {
+	m.retries.WithLabelValues(operation, gateway).Inc()
+}
+
+// SignatureFailure records a gateway response which failed signature verification.
+func (m *Metrics) SignatureFailure(operation string, gateway string) {
+	m.signatureFailures.WithLabelValues(operation, gateway).Inc()
+}
+
+// ProtocolError records an unexpected or undecodable gateway response.
+func (m *Metrics) ProtocolError(operation string, gateway string) {
+	m.protocolErrors.WithLabelValues(operation, gateway).Inc()
+}
+
+// KeyChange records a gateway response signed with a key which conflicts with the gateway's known keys.
+func (m *Metrics) KeyChange(gateway string) {
+	m.keyChanges.WithLabelValues(gateway).Inc()
+}
+
+// SetPooledConnections sets the number of open pooled connections.
+func (m *Metrics) SetPooledConnections(count int) {
+	m.pooledConnections.Set(float64(count))
+}
+
+// SetReachable records whether the last attempt to reach a gateway succeeded.
+func (m *Metrics) SetReachable(gateway string, reachable bool) {
+	value := 0.0
+	if reachable {
+		value = 1.0
+	}
+	m.reachable.WithLabelValues(gateway).Set(value)
+}
+
+// Shutdown stops the metrics endpoint, if one was started.
+func (m *Metrics) Shutdown() {
+	if m.server != nil {
+		m.server.Close()
+	}
+}
@@ -0,0 +1,81 @@
+package offerexport
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Format is an offer export format.
+type Format string
+
+// Supported formats. The binary format is a header followed by length prefixed records;
+// see binaryWriter.
+const (
+	FormatJSONL  Format = "jsonl"
+	FormatCSV    Format = "csv"
+	FormatBinary Format = "bin"
+)
+
+var csvHeader = []string{"provider_id", "merkle_root", "price_per_byte", "expiry_date", "qos", "signature", "cids"}
+
+// ParseFormat returns the named format.
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatJSONL, "json":
+		return FormatJSONL, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatBinary, "binary":
+		return FormatBinary, nil
+	}
+	return "", fmt.Errorf("Unsupported offer export format %q: expected jsonl, csv or bin", name)
+}
+
+// FormatFromPath returns the format matching the file extension.
+func FormatFromPath(path string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// ResumeToken records how far an export got, so that it can be continued.
+type ResumeToken struct {
+	Gateway string `json:"g"`
+	Cursor  string `json:"c"`
+	Count   int    `json:"n"`
+}
+
+// Encode returns the token as an opaque string.
+func (t *ResumeToken) Encode() string {
+	raw, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeResumeToken parses a token returned by Encode.
+func DecodeResumeToken(token string) (*ResumeToken, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid resume token: %s", err)
+	}
+	var t ResumeToken
+	if err = json.Unmarshal(raw, &t); err != nil {
+		return nil, fmt.Errorf("Invalid resume token: %s", err)
+	}
+	return &t, nil
+}
@@ -0,0 +1,198 @@
+package offerexport
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+// maxBinaryRecord bounds the size of a binary record, so a corrupt length can not
+// exhaust memory.
+const maxBinaryRecord = 64 * 1024 * 1024
+
+// Reader reads offers written by a Writer.
+type Reader interface {
+	// Next returns the next offer, or io.EOF at the end of the export.
+	Next() (*fcradminmessages.Offer, error)
+}
+
+// NewReader creates a reader for the format.
+func NewReader(r io.Reader, format Format) (Reader, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlReader{dec: json.NewDecoder(r)}, nil
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = len(csvHeader)
+		return &csvReader{r: cr}, nil
+	case FormatBinary:
+		br := bufio.NewReader(r)
+		header := make([]byte, len(binaryMagic)+1)
+		if _, err := io.ReadFull(br, header); err != nil {
+			return nil, fmt.Errorf("Invalid binary offer export: %s", err)
+		}
+		if string(header[:len(binaryMagic)]) != binaryMagic {
+			return nil, errors.New("Invalid binary offer export: bad header")
+		}
+		if header[len(binaryMagic)] != binaryVersion {
+			return nil, fmt.Errorf("Unsupported binary offer export version %d", header[len(binaryMagic)])
+		}
+		return &binaryReader{r: br}, nil
+	}
+	return nil, fmt.Errorf("Unsupported offer export format %q", format)
+}
+
+type jsonlReader struct {
+	dec *json.Decoder
+}
+
+func (j *jsonlReader) Next() (*fcradminmessages.Offer, error) {
+	var offer fcradminmessages.Offer
+	if err := j.dec.Decode(&offer); err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	record int
+}
+
+func (c *csvReader) Next() (*fcradminmessages.Offer, error) {
+	fields, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	c.record++
+	if c.record == 1 && fields[0] == csvHeader[0] {
+		return c.Next()
+	}
+	offer, err := parseCSVOffer(fields)
+	if err != nil {
+		return nil, fmt.Errorf("Record %d: %s", c.record, err)
+	}
+	return offer, nil
+}
+
+func parseCSVOffer(fields []string) (*fcradminmessages.Offer, error) {
+	var offer fcradminmessages.Offer
+	providerID, err := nodeid.NewNodeIDFromString(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid provider ID: %s", err)
+	}
+	offer.ProviderID = *providerID
+	offer.MerkleRoot = fields[1]
+	if offer.Price, err = strconv.ParseUint(fields[2], 10, 64); err != nil {
+		return nil, fmt.Errorf("Invalid price: %s", err)
+	}
+	if offer.Expiry, err = strconv.ParseInt(fields[3], 10, 64); err != nil {
+		return nil, fmt.Errorf("Invalid expiry: %s", err)
+	}
+	if offer.QoS, err = strconv.ParseUint(fields[4], 10, 64); err != nil {
+		return nil, fmt.Errorf("Invalid QoS: %s", err)
+	}
+	offer.Signature = fields[5]
+	for _, c := range strings.Fields(fields[6]) {
+		raw, err := hex.DecodeString(c)
+		if err != nil || len(raw) != idSize {
+			return nil, fmt.Errorf("Invalid CID %q", c)
+		}
+		offer.Cids = append(offer.Cids, *cid.NewContentIDFromBytes(raw))
+	}
+	return &offer, nil
+}
+
+type binaryReader struct {
+	r *bufio.Reader
+}
+
+func (b *binaryReader) Next() (*fcradminmessages.Offer, error) {
+	size, err := binary.ReadUvarint(b.r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxBinaryRecord {
+		return nil, fmt.Errorf("Invalid binary offer export: record of %d bytes", size)
+	}
+	rec := make([]byte, size)
+	if _, err = io.ReadFull(b.r, rec); err != nil {
+		return nil, fmt.Errorf("Invalid binary offer export: %s", err)
+	}
+	offer, err := parseBinaryOffer(bytes.NewReader(rec))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid binary offer export: %s", err)
+	}
+	return offer, nil
+}
+
+func parseBinaryOffer(r *bytes.Reader) (*fcradminmessages.Offer, error) {
+	var offer fcradminmessages.Offer
+	id := make([]byte, idSize)
+	if _, err := io.ReadFull(r, id); err != nil {
+		return nil, err
+	}
+	providerID, err := nodeid.NewNodeIDFromBytes(id)
+	if err != nil {
+		return nil, err
+	}
+	offer.ProviderID = *providerID
+	if offer.Price, err = binary.ReadUvarint(r); err != nil {
+		return nil, err
+	}
+	if offer.Expiry, err = binary.ReadVarint(r); err != nil {
+		return nil, err
+	}
+	if offer.QoS, err = binary.ReadUvarint(r); err != nil {
+		return nil, err
+	}
+	if offer.MerkleRoot, err = readString(r); err != nil {
+		return nil, err
+	}
+	if offer.Signature, err = readString(r); err != nil {
+		return nil, err
+	}
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(r.Len()/idSize) {
+		return nil, fmt.Errorf("CID count %d exceeds record size", count)
+	}
+	offer.Cids = make([]cid.ContentID, count)
+	for i := range offer.Cids {
+		if _, err = io.ReadFull(r, id); err != nil {
+			return nil, err
+		}
+		offer.Cids[i] = *cid.NewContentIDFromBytes(id)
+	}
+	return &offer, nil
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if size > uint64(r.Len()) {
+		return "", fmt.Errorf("String of %d bytes exceeds record size", size)
+	}
+	s := make([]byte, size)
+	_, err = io.ReadFull(r, s)
+	return string(s), err
+}
@@ -0,0 +1,159 @@
+package offerexport
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+const (
+	binaryMagic   = "FCRO"
+	binaryVersion = byte(1)
+	idSize        = 32
+)
+
+// Writer writes offers in an export format.
+type Writer interface {
+	// Write writes one offer.
+	Write(offer *fcradminmessages.Offer) error
+	// Flush writes any buffered offers to the underlying writer.
+	Flush() error
+}
+
+// NewWriter creates a writer for the format. If resume is true the file header is not
+// written, as the output is being appended to an earlier export.
+func NewWriter(w io.Writer, format Format, resume bool) (Writer, error) {
+	switch format {
+	case FormatJSONL:
+		bw := bufio.NewWriter(w)
+		return &jsonlWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if !resume {
+			if err := cw.Write(csvHeader); err != nil {
+				return nil, err
+			}
+		}
+		return &csvWriter{w: cw}, nil
+	case FormatBinary:
+		bw := bufio.NewWriter(w)
+		if !resume {
+			if _, err := bw.WriteString(binaryMagic); err != nil {
+				return nil, err
+			}
+			if err := bw.WriteByte(binaryVersion); err != nil {
+				return nil, err
+			}
+		}
+		return &binaryWriter{w: bw}, nil
+	}
+	return nil, fmt.Errorf("Unsupported offer export format %q", format)
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(offer *fcradminmessages.Offer) error {
+	return j.enc.Encode(offer)
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.w.Flush()
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Write(offer *fcradminmessages.Offer) error {
+	cids := make([]string, len(offer.Cids))
+	for i := range offer.Cids {
+		cids[i] = offer.Cids[i].ToString()
+	}
+	return c.w.Write([]string{
+		offer.ProviderID.ToString(),
+		offer.MerkleRoot,
+		strconv.FormatUint(offer.Price, 10),
+		strconv.FormatInt(offer.Expiry, 10),
+		strconv.FormatUint(offer.QoS, 10),
+		offer.Signature,
+		strings.Join(cids, " "),
+	})
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// binaryWriter writes each offer as a uvarint record length followed by the record:
+// the 32 byte provider ID, uvarint price, varint expiry, uvarint QoS, the merkle root and
+// signature as uvarint length prefixed strings, a uvarint CID count and the 32 byte CIDs.
+type binaryWriter struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+func (b *binaryWriter) Write(offer *fcradminmessages.Offer) error {
+	rec := b.buf[:0]
+	rec = append(rec, fixedID(offer.ProviderID.ToBytes())...)
+	rec = appendUvarint(rec, offer.Price)
+	rec = appendVarint(rec, offer.Expiry)
+	rec = appendUvarint(rec, offer.QoS)
+	rec = appendString(rec, offer.MerkleRoot)
+	rec = appendString(rec, offer.Signature)
+	rec = appendUvarint(rec, uint64(len(offer.Cids)))
+	for i := range offer.Cids {
+		rec = append(rec, fixedID(offer.Cids[i].ToBytes())...)
+	}
+	b.buf = rec
+
+	if _, err := b.w.Write(appendUvarint(nil, uint64(len(rec)))); err != nil {
+		return err
+	}
+	_, err := b.w.Write(rec)
+	return err
+}
+
+func (b *binaryWriter) Flush() error {
+	return b.w.Flush()
+}
+
+func fixedID(id []byte) []byte {
+	fixed := make([]byte, idSize)
+	copy(fixed[idSize-minInt(len(id), idSize):], id)
+	return fixed
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(b, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(b, tmp[:binary.PutVarint(tmp[:], v)]...)
+}
+
+func appendString(b []byte, s string) []byte {
+	b = appendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
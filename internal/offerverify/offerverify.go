@@ -0,0 +1,137 @@
+package offerverify
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/cidoffer"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+// Status is the outcome of verifying an offer.
+type Status string
+
+// Offer verification outcomes.
+const (
+	StatusValid            Status = "valid"
+	StatusInvalidSignature Status = "invalid-signature"
+	StatusMerkleMismatch   Status = "merkle-root-mismatch"
+	StatusMissingCids      Status = "missing-cids"
+	StatusExpired          Status = "expired"
+	StatusUnknownProvider  Status = "unknown-provider"
+	StatusBadProviderKey   Status = "bad-provider-key"
+)
+
+// signatureHeaderSize is the length of the key version which prefixes each signature.
+const signatureHeaderSize = 4
+
+// Result is the verification of one offer.
+type Result struct {
+	Offer  *fcradminmessages.Offer `json:"offer"`
+	Status Status                  `json:"status"`
+	Detail string                  `json:"detail,omitempty"`
+}
+
+// Valid returns true if the offer passed every check.
+func (r *Result) Valid() bool {
+	return r.Status == StatusValid
+}
+
+// Verifier checks offers against the signing keys of the providers in the register.
+//
+// The merkle root of each offer is recalculated from its CIDs and its signature checked
+// with the provider's key. Note that the message signing scheme only covers the string
+// fields of the signed structure, so of an offer only the merkle root is bound by the
+// signature; the price, expiry and QoS are not.
+type Verifier struct {
+	keys    map[string]*fcrcrypto.KeyPair
+	badKeys map[string]string
+	now     func() time.Time
+}
+
+// New creates a verifier for the registered providers.
+func New(providers []register.ProviderRegister) *Verifier {
+	v := Verifier{
+		keys:    make(map[string]*fcrcrypto.KeyPair, len(providers)),
+		badKeys: make(map[string]string),
+		now:     time.Now,
+	}
+	for _, p := range providers {
+		key := strings.ToLower(p.NodeID)
+		pubKey, err := p.GetSigningKey()
+		if err != nil {
+			v.badKeys[key] = err.Error()
+			continue
+		}
+		v.keys[key] = pubKey
+	}
+	return &v
+}
+
+// Verify checks one offer.
+func (v *Verifier) Verify(offer *fcradminmessages.Offer) Result {
+	result := Result{Offer: offer, Status: StatusValid}
+	providerID := offer.ProviderID.ToString()
+	pubKey, ok := v.keys[strings.ToLower(providerID)]
+	if !ok {
+		if reason, bad := v.badKeys[strings.ToLower(providerID)]; bad {
+			return fail(result, StatusBadProviderKey, reason)
+		}
+		return fail(result, StatusUnknownProvider, "Provider "+providerID+" is not in the register")
+	}
+
+	// Without its CIDs the merkle root, the only field the signature binds, can not be checked.
+	if len(offer.Cids) == 0 {
+		return fail(result, StatusMissingCids, "Offer has no CIDs to check the merkle root against")
+	}
+	cids := offer.Cids
+	rebuilt, err := cidoffer.NewCidGroupOffer(&offer.ProviderID, &cids, offer.Price, offer.Expiry, offer.QoS)
+	if err != nil {
+		return fail(result, StatusMerkleMismatch, err.Error())
+	}
+	if rebuilt.MerkleRoot != offer.MerkleRoot {
+		return fail(result, StatusMerkleMismatch, "Merkle root does not match the offer's CIDs")
+	}
+
+	sig, err := hex.DecodeString(offer.Signature)
+	if err != nil || len(sig) <= signatureHeaderSize {
+		return fail(result, StatusInvalidSignature, "Malformed signature")
+	}
+	ok, err = fcrcrypto.VerifyMessage(pubKey, offer.Signature, offer.SigningFields())
+	if err != nil {
+		return fail(result, StatusInvalidSignature, err.Error())
+	}
+	if !ok {
+		return fail(result, StatusInvalidSignature, "Signature does not match the provider's registered key")
+	}
+
+	if time.Unix(offer.Expiry, 0).Before(v.now()) {
+		return fail(result, StatusExpired, "Expired at "+time.Unix(offer.Expiry, 0).UTC().Format(time.RFC3339))
+	}
+	return result
+}
+
+func fail(result Result, status Status, detail string) Result {
+	result.Status = status
+	result.Detail = detail
+	return result
+}
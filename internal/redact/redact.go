@@ -0,0 +1,162 @@
+package redact
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+// Mask replaces redacted values in log output.
+const Mask = "[REDACTED]"
+
+// DefaultSensitiveFields are the JSON field names that are always masked. Matching
+// ignores case, underscores and hyphens.
+var DefaultSensitiveFields = []string{
+	"privatekey",
+	"message_signature",
+	"signature",
+	"secret",
+	"password",
+}
+
+// Long runs of hex are treated as key material when they appear in free text.
+var hexKeyPattern = regexp.MustCompile(`[0-9a-fA-F]{64,}`)
+
+// Redactor masks key material, signatures and sensitive fields in log and debug output.
+type Redactor struct {
+	fields map[string]bool
+	unsafe bool
+}
+
+// New creates a redactor which masks the default sensitive fields plus any extra
+// fields given. If unsafe is true nothing is masked: this must only be used for
+// local debugging as it writes private keys to the logs.
+func New(extraFields []string, unsafe bool) *Redactor {
+	r := Redactor{fields: make(map[string]bool), unsafe: unsafe}
+	for _, f := range DefaultSensitiveFields {
+		r.fields[normaliseField(f)] = true
+	}
+	for _, f := range extraFields {
+		r.fields[normaliseField(f)] = true
+	}
+	return &r
+}
+
+// Unsafe returns true if the redactor is passing output through unmodified.
+func (r *Redactor) Unsafe() bool {
+	return r.unsafe
+}
+
+// Message returns a printable form of a message with its signature and any
+// sensitive body fields masked. The message itself is not modified.
+func (r *Redactor) Message(msg *fcrmessages.FCRMessage) string {
+	if msg == nil {
+		return "<nil>"
+	}
+	if r.unsafe {
+		return msg.DumpMessage()
+	}
+	var body interface{}
+	if err := json.Unmarshal(msg.MessageBody, &body); err != nil {
+		body = fmt.Sprintf("[%d byte body]", len(msg.MessageBody))
+	}
+	printable := map[string]interface{}{
+		"message_type":       msg.MessageType,
+		"protocol_version":   msg.ProtocolVersion,
+		"protocol_supported": msg.ProtocolSupported,
+		"message_body":       r.redactValue(body),
+		"message_signature":  r.maskIfSet(msg.Signature),
+	}
+	out, err := json.Marshal(printable)
+	if err != nil {
+		return "Error processing message"
+	}
+	return string(out)
+}
+
+// JSON returns a printable form of a JSON document with sensitive fields masked.
+// Input which is not valid JSON is treated as free text.
+func (r *Redactor) JSON(data []byte) string {
+	if r.unsafe {
+		return string(data)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return r.String(string(data))
+	}
+	out, err := json.Marshal(r.redactValue(doc))
+	if err != nil {
+		return Mask
+	}
+	return string(out)
+}
+
+// String masks anything in free text which looks like key material.
+func (r *Redactor) String(s string) string {
+	if r.unsafe {
+		return s
+	}
+	return hexKeyPattern.ReplaceAllString(s, Mask)
+}
+
+// Field returns the value, or the mask if the named field is sensitive.
+func (r *Redactor) Field(name string, value string) string {
+	if !r.unsafe && r.fields[normaliseField(name)] {
+		return r.maskIfSet(value)
+	}
+	return r.String(value)
+}
+
+func (r *Redactor) redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, field := range val {
+			if r.fields[normaliseField(k)] {
+				val[k] = Mask
+			} else {
+				val[k] = r.redactValue(field)
+			}
+		}
+		return val
+	case []interface{}:
+		for i := range val {
+			val[i] = r.redactValue(val[i])
+		}
+		return val
+	case string:
+		return r.String(val)
+	default:
+		return val
+	}
+}
+
+func (r *Redactor) maskIfSet(value string) string {
+	if value == "" {
+		return ""
+	}
+	return Mask
+}
+
+func normaliseField(name string) string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, "_", "")
+	return strings.ReplaceAll(name, "-", "")
+}
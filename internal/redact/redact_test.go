@@ -0,0 +1,88 @@
+package redact
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+var hexKey = strings.Repeat("ab01", 16)
+
+func TestJSON(t *testing.T) {
+	r := New([]string{"api-token"}, false)
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"default field", `{"private_key":"abc"}`, `{"private_key":"[REDACTED]"}`},
+		{"field case and separators", `{"Private-Key":"abc","SIGNATURE":"def"}`, `{"Private-Key":"[REDACTED]","SIGNATURE":"[REDACTED]"}`},
+		{"extra field", `{"api_token":"abc","name":"gw"}`, `{"api_token":"[REDACTED]","name":"gw"}`},
+		{"nested field", `{"gateway":{"keys":[{"secret":1}]}}`, `{"gateway":{"keys":[{"secret":"[REDACTED]"}]}}`},
+		{"key in value", `{"note":"key ` + hexKey + `"}`, `{"note":"key [REDACTED]"}`},
+		{"short hex kept", `{"node_id":"0102030405"}`, `{"node_id":"0102030405"}`},
+		{"not JSON", `key=` + hexKey, `key=[REDACTED]`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := r.JSON([]byte(test.in)); got != test.want {
+				t.Errorf("JSON(%s) = %s, want %s", test.in, got, test.want)
+			}
+		})
+	}
+}
+
+func TestJSONUnsafe(t *testing.T) {
+	in := `{"private_key":"` + hexKey + `"}`
+	if got := New(nil, true).JSON([]byte(in)); got != in {
+		t.Errorf("unsafe JSON(%s) = %s, want it unchanged", in, got)
+	}
+}
+
+func TestField(t *testing.T) {
+	r := New(nil, false)
+	tests := []struct {
+		name  string
+		field string
+		value string
+		want  string
+	}{
+		{"sensitive", "privateKey", "abc", Mask},
+		{"sensitive and empty", "password", "", ""},
+		{"plain", "node_id", "abc", "abc"},
+		{"plain with key material", "note", hexKey, Mask},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := r.Field(test.field, test.value); got != test.want {
+				t.Errorf("Field(%s, %s) = %s, want %s", test.field, test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	msg := &fcrmessages.FCRMessage{
+		MessageType:       1,
+		ProtocolVersion:   1,
+		ProtocolSupported: []int32{1},
+		MessageBody:       []byte(`{"private_key":"abc","node_id":"01"}`),
+		Signature:         "00000001" + hexKey,
+	}
+	got := New(nil, false).Message(msg)
+	if strings.Contains(got, hexKey) || strings.Contains(got, `"abc"`) {
+		t.Errorf("Message(%+v) = %s, which holds the signature or private key", msg, got)
+	}
+	if !strings.Contains(got, `"node_id":"01"`) {
+		t.Errorf("Message(%+v) = %s, which lost the node ID", msg, got)
+	}
+	if string(msg.MessageBody) != `{"private_key":"abc","node_id":"01"}` || msg.Signature == Mask {
+		t.Error("Message modified the message")
+	}
+	if got := New(nil, false).Message(nil); got != "<nil>" {
+		t.Errorf("Message(nil) = %s, want <nil>", got)
+	}
+}
@@ -0,0 +1,225 @@
+package replay
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+const (
+	// NonceField is the message body field carrying the request nonce. Responses echo it.
+	NonceField = "nonce"
+	// ExpiryField is the message body field carrying the Unix time after which a message must be rejected.
+	ExpiryField = "expiry"
+
+	nonceLength = 16
+)
+
+// Stamp is the nonce and expiry added to a request.
+type Stamp struct {
+	Nonce  string
+	Expiry int64
+}
+
+// Guard adds nonces and expiry times to admin requests and checks that responses
+// echo the nonce of the request they answer and arrive before it expires. Gateways use
+// CheckRequest and StampResponse for their side of the exchange.
+//
+// The nonce and expiry are carried in the message body, which is covered by the signature
+// of the message digest made by fcradminmessages.Sign. The guard relies on that: a message
+// must have its signature verified before it is checked.
+type Guard struct {
+	ttl         time.Duration
+	allowLegacy bool
+	lock        sync.Mutex
+	// seen holds the nonces of accepted responses until they expire, so a replayed response is rejected.
+	seen map[string]int64
+}
+
+// NewGuard creates a guard. Requests expire ttl after they are stamped. If allowLegacy is
+// true, responses from gateways which do not echo nonces are accepted, and those responses
+// can be replayed.
+func NewGuard(ttl time.Duration, allowLegacy bool) *Guard {
+	return &Guard{
+		ttl:         ttl,
+		allowLegacy: allowLegacy,
+		seen:        make(map[string]int64),
+	}
+}
+
+// Stamp adds a fresh nonce and an expiry time to the body of a request. It must be
+// called before the request is signed.
+func (g *Guard) Stamp(request *fcrmessages.FCRMessage) (*Stamp, error) {
+	body, err := decodeBody(request)
+	if err != nil {
+		return nil, err
+	}
+	raw := make([]byte, nonceLength)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	stamp := Stamp{
+		Nonce:  hex.EncodeToString(raw),
+		Expiry: time.Now().Add(g.ttl).Unix(),
+	}
+	if body[NonceField], err = json.Marshal(stamp.Nonce); err != nil {
+		return nil, err
+	}
+	if body[ExpiryField], err = json.Marshal(stamp.Expiry); err != nil {
+		return nil, err
+	}
+	request.MessageBody, err = json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &stamp, nil
+}
+
+// Check verifies that a response answers the stamped request: it must echo the nonce,
+// arrive before the request expired, not have expired itself and not have been seen before.
+func (g *Guard) Check(response *fcrmessages.FCRMessage, stamp *Stamp) error {
+	now := time.Now().Unix()
+	if now > stamp.Expiry {
+		return errors.New("Stale response: request expired before the response arrived")
+	}
+
+	body, err := decodeBody(response)
+	if err != nil {
+		return err
+	}
+	rawNonce, ok := body[NonceField]
+	if !ok {
+		if g.allowLegacy {
+			return nil
+		}
+		return errors.New("Response does not echo the request nonce")
+	}
+	var nonce string
+	if err := json.Unmarshal(rawNonce, &nonce); err != nil {
+		return fmt.Errorf("Invalid response nonce: %s", err)
+	}
+	if nonce != stamp.Nonce {
+		return errors.New("Response nonce does not match the request")
+	}
+	expiry := stamp.Expiry
+	if rawExpiry, ok := body[ExpiryField]; ok {
+		if err := json.Unmarshal(rawExpiry, &expiry); err != nil {
+			return fmt.Errorf("Invalid response expiry: %s", err)
+		}
+		if now > expiry {
+			return errors.New("Stale response: response has expired")
+		}
+	}
+
+	if err := g.remember(nonce, expiry, now); err != nil {
+		return errors.New("Replayed response: nonce already used")
+	}
+	return nil
+}
+
+// CheckRequest is the gateway's check of a request: it must carry a nonce and an expiry, not
+// have expired and not repeat the nonce of an earlier request. It returns the stamp to echo
+// in the response with StampResponse. The request's signature must be verified first.
+func (g *Guard) CheckRequest(request *fcrmessages.FCRMessage) (*Stamp, error) {
+	body, err := decodeBody(request)
+	if err != nil {
+		return nil, err
+	}
+	var stamp Stamp
+	rawNonce, ok := body[NonceField]
+	if !ok {
+		return nil, errors.New("Request has no nonce")
+	}
+	if err := json.Unmarshal(rawNonce, &stamp.Nonce); err != nil || stamp.Nonce == "" {
+		return nil, errors.New("Invalid request nonce")
+	}
+	rawExpiry, ok := body[ExpiryField]
+	if !ok {
+		return nil, errors.New("Request has no expiry")
+	}
+	if err := json.Unmarshal(rawExpiry, &stamp.Expiry); err != nil {
+		return nil, fmt.Errorf("Invalid request expiry: %s", err)
+	}
+	now := time.Now().Unix()
+	if now > stamp.Expiry {
+		return nil, errors.New("Stale request: request has expired")
+	}
+	if g.ttl > 0 && stamp.Expiry > time.Now().Add(g.ttl).Unix() {
+		// A nonce is only remembered until its expiry, so a far future expiry would let the
+		// request be held for longer than the gateway remembers.
+		return nil, errors.New("Request expiry is too far in the future")
+	}
+	if err := g.remember(stamp.Nonce, stamp.Expiry, now); err != nil {
+		return nil, errors.New("Replayed request: nonce already used")
+	}
+	return &stamp, nil
+}
+
+// StampResponse echoes the nonce and expiry of the request a response answers. It must be
+// called before the response is signed.
+func (g *Guard) StampResponse(response *fcrmessages.FCRMessage, stamp *Stamp) error {
+	body, err := decodeBody(response)
+	if err != nil {
+		return err
+	}
+	if body[NonceField], err = json.Marshal(stamp.Nonce); err != nil {
+		return err
+	}
+	if body[ExpiryField], err = json.Marshal(stamp.Expiry); err != nil {
+		return err
+	}
+	response.MessageBody, err = json.Marshal(body)
+	return err
+}
+
+// remember records a nonce until its expiry, returning an error if it is already recorded.
+func (g *Guard) remember(nonce string, expiry int64, now int64) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for n, exp := range g.seen {
+		if now > exp {
+			delete(g.seen, n)
+		}
+	}
+	if _, replayed := g.seen[nonce]; replayed {
+		return errors.New("Nonce already used")
+	}
+	g.seen[nonce] = expiry
+	return nil
+}
+
+func decodeBody(msg *fcrmessages.FCRMessage) (map[string]json.RawMessage, error) {
+	body := make(map[string]json.RawMessage)
+	if len(msg.MessageBody) == 0 {
+		return body, nil
+	}
+	if err := json.Unmarshal(msg.MessageBody, &body); err != nil {
+		return nil, fmt.Errorf("Message body is not a JSON object: %s", err)
+	}
+	if body == nil {
+		// The body was JSON null.
+		body = make(map[string]json.RawMessage)
+	}
+	return body, nil
+}
@@ -0,0 +1,152 @@
+package replay
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+func newMessage(t *testing.T, body map[string]interface{}) *fcrmessages.FCRMessage {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fcrmessages.FCRMessage{MessageType: 1, MessageBody: raw}
+}
+
+func TestStampAddsNonceAndExpiry(t *testing.T) {
+	g := NewGuard(time.Minute, false)
+	request := newMessage(t, map[string]interface{}{"client_id": "abc"})
+	stamp, err := g.Stamp(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stamp.Nonce) != 2*nonceLength {
+		t.Errorf("nonce %q has length %d, want %d", stamp.Nonce, len(stamp.Nonce), 2*nonceLength)
+	}
+	if stamp.Expiry <= time.Now().Unix() {
+		t.Errorf("expiry %d is not in the future", stamp.Expiry)
+	}
+	body, err := decodeBody(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body["client_id"]) != `"abc"` {
+		t.Errorf("stamp changed the body field client_id to %s", body["client_id"])
+	}
+	other, err := g.Stamp(newMessage(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Nonce == stamp.Nonce {
+		t.Error("two stamps have the same nonce")
+	}
+}
+
+func TestStampRejectsNonObjectBody(t *testing.T) {
+	g := NewGuard(time.Minute, false)
+	if _, err := g.Stamp(&fcrmessages.FCRMessage{MessageBody: []byte(`[1,2]`)}); err == nil {
+		t.Fatal("stamped a body which is not a JSON object")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now().Unix()
+	stamp := &Stamp{Nonce: "00112233", Expiry: now + 60}
+	tests := []struct {
+		name        string
+		allowLegacy bool
+		stamp       *Stamp
+		body        map[string]interface{}
+		wantErr     bool
+	}{
+		{"echoed nonce", false, stamp, map[string]interface{}{NonceField: stamp.Nonce}, false},
+		{"echoed nonce and expiry", false, stamp, map[string]interface{}{NonceField: stamp.Nonce, ExpiryField: now + 60}, false},
+		{"missing nonce", false, stamp, map[string]interface{}{}, true},
+		{"missing nonce allowed for legacy gateways", true, stamp, map[string]interface{}{}, false},
+		{"wrong nonce", true, stamp, map[string]interface{}{NonceField: "ffff"}, true},
+		{"nonce not a string", false, stamp, map[string]interface{}{NonceField: 7}, true},
+		{"expired response", false, stamp, map[string]interface{}{NonceField: stamp.Nonce, ExpiryField: now - 10}, true},
+		{"expired request", false, &Stamp{Nonce: "aa", Expiry: now - 1}, map[string]interface{}{NonceField: "aa"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGuard(time.Minute, tt.allowLegacy)
+			err := g.Check(newMessage(t, tt.body), tt.stamp)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckRejectsReplayedResponse(t *testing.T) {
+	g := NewGuard(time.Minute, false)
+	stamp := &Stamp{Nonce: "0102", Expiry: time.Now().Unix() + 60}
+	response := newMessage(t, map[string]interface{}{NonceField: stamp.Nonce})
+	if err := g.Check(response, stamp); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Check(response, stamp); err == nil {
+		t.Fatal("accepted a replayed response")
+	}
+}
+
+func TestCheckRequest(t *testing.T) {
+	now := time.Now().Unix()
+	tests := []struct {
+		name    string
+		body    map[string]interface{}
+		wantErr bool
+	}{
+		{"valid", map[string]interface{}{NonceField: "aa01", ExpiryField: now + 30}, false},
+		{"no nonce", map[string]interface{}{ExpiryField: now + 30}, true},
+		{"empty nonce", map[string]interface{}{NonceField: "", ExpiryField: now + 30}, true},
+		{"no expiry", map[string]interface{}{NonceField: "aa02"}, true},
+		{"expired", map[string]interface{}{NonceField: "aa03", ExpiryField: now - 1}, true},
+		{"expiry beyond the ttl", map[string]interface{}{NonceField: "aa04", ExpiryField: now + 3600}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGuard(time.Minute, false)
+			_, err := g.CheckRequest(newMessage(t, tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckRequest() error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	client := NewGuard(time.Minute, false)
+	gateway := NewGuard(time.Minute, false)
+
+	request := newMessage(t, map[string]interface{}{"client_id": "abc"})
+	stamp, err := client.Stamp(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received, err := gateway.CheckRequest(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *received != *stamp {
+		t.Fatalf("gateway read stamp %+v, want %+v", received, stamp)
+	}
+	if _, err := gateway.CheckRequest(request); err == nil {
+		t.Fatal("gateway accepted a replayed request")
+	}
+
+	response := newMessage(t, map[string]interface{}{"exists": true})
+	if err := gateway.StampResponse(response, received); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Check(response, stamp); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,180 @@
+package reputationfile
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+)
+
+// Format is a reputation file format.
+type Format string
+
+// Supported formats.
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+var csvHeader = []string{"client_id", "reputation"}
+
+// Record is the reputation of one client.
+type Record struct {
+	ClientID   string `json:"client_id"`
+	Reputation int64  `json:"reputation"`
+}
+
+// Row is a record read from a reputation file. Line is the CSV line number, or the
+// position in the JSON array, counting from one. Err is set if the row is invalid.
+type Row struct {
+	Line int
+	Record
+	Err error
+}
+
+// Document is the JSON form of a reputation file.
+type Document struct {
+	Gateway     string    `json:"gateway,omitempty"`
+	ExportedAt  time.Time `json:"exported_at,omitempty"`
+	Reputations []Record  `json:"reputations"`
+}
+
+// FormatFromPath returns the format matching the file extension.
+func FormatFromPath(path string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// ParseFormat returns the named format.
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("Unsupported reputation file format %q: expected csv or json", name)
+}
+
+// Write writes the reputations of a gateway's clients.
+func Write(w io.Writer, format Format, gateway string, records []Record) error {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := cw.Write([]string{r.ClientID, strconv.FormatInt(r.Reputation, 10)}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(Document{Gateway: gateway, ExportedAt: time.Now().UTC(), Reputations: records})
+	}
+	return fmt.Errorf("Unsupported reputation file format %q", format)
+}
+
+// Read reads and validates a reputation file. A file which can not be parsed at all
+// returns an error; otherwise each invalid row is returned with its Err set.
+func Read(r io.Reader, format Format) ([]Row, error) {
+	var rows []Row
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		line := 0
+		for {
+			fields, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			line++
+			if err != nil {
+				return nil, err
+			}
+			if line == 1 && len(fields) > 0 && strings.EqualFold(fields[0], csvHeader[0]) {
+				continue
+			}
+			rows = append(rows, parseCSVRow(line, fields))
+		}
+	case FormatJSON:
+		var doc Document
+		if err := json.NewDecoder(r).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("Invalid reputation file: %s", err)
+		}
+		for i, rec := range doc.Reputations {
+			rows = append(rows, Row{Line: i + 1, Record: rec, Err: validateClientID(rec.ClientID)})
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported reputation file format %q", format)
+	}
+
+	seen := make(map[string]int, len(rows))
+	for i := range rows {
+		if rows[i].Err != nil {
+			continue
+		}
+		key := strings.ToLower(rows[i].ClientID)
+		if first, ok := seen[key]; ok {
+			rows[i].Err = fmt.Errorf("Duplicate client ID, first seen at line %d", first)
+			continue
+		}
+		seen[key] = rows[i].Line
+	}
+	return rows, nil
+}
+
+func parseCSVRow(line int, fields []string) Row {
+	row := Row{Line: line}
+	if len(fields) != len(csvHeader) {
+		row.Err = fmt.Errorf("Expected %d fields, found %d", len(csvHeader), len(fields))
+		return row
+	}
+	row.ClientID = strings.TrimSpace(fields[0])
+	if row.Err = validateClientID(row.ClientID); row.Err != nil {
+		return row
+	}
+	rep, err := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+	if err != nil {
+		row.Err = fmt.Errorf("Invalid reputation %q", fields[1])
+		return row
+	}
+	row.Reputation = rep
+	return row
+}
+
+func validateClientID(clientID string) error {
+	if clientID == "" {
+		return fmt.Errorf("Missing client ID")
+	}
+	if _, err := nodeid.NewNodeIDFromString(clientID); err != nil {
+		return fmt.Errorf("Invalid client ID %q: %s", clientID, err)
+	}
+	return nil
+}
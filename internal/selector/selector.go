@@ -0,0 +1,240 @@
+// Package selector implements label selectors for choosing gateways by their tags. A selector is a comma separated
+// list of requirements, all of which must match:
+//
+//	region=eu          tag equals value (== is also accepted)
+//	tier!=edge         tag is absent or does not equal value
+//	region in (eu,us)  tag is one of the values
+//	tier notin (core)  tag is absent or is none of the values
+//	canary             tag is present
+//	!canary            tag is absent
+package selector
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type operator int
+
+const (
+	opEquals operator = iota
+	opNotEquals
+	opIn
+	opNotIn
+	opExists
+	opNotExists
+)
+
+var (
+	tagKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	tagValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?)?$`)
+	setPattern      = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+)
+
+type requirement struct {
+	key    string
+	op     operator
+	values []string
+}
+
+// Selector chooses a set of tagged objects.
+type Selector struct {
+	requirements []requirement
+	text         string
+}
+
+// Parse parses a selector. The empty selector matches everything.
+func Parse(text string) (*Selector, error) {
+	s := Selector{text: strings.TrimSpace(text)}
+	if s.text == "" {
+		return &s, nil
+	}
+	terms, err := splitTerms(s.text)
+	if err != nil {
+		return nil, err
+	}
+	for _, term := range terms {
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+		s.requirements = append(s.requirements, r)
+	}
+	return &s, nil
+}
+
+// Everything returns a selector which matches everything.
+func Everything() *Selector {
+	return &Selector{}
+}
+
+// Matches returns true if the tags satisfy every requirement of the selector.
+func (s *Selector) Matches(tags map[string]string) bool {
+	for _, r := range s.requirements {
+		if !r.matches(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty returns true if the selector matches everything.
+func (s *Selector) Empty() bool {
+	return len(s.requirements) == 0
+}
+
+// String returns the selector as it was parsed.
+func (s *Selector) String() string {
+	return s.text
+}
+
+// ValidateTag checks that a tag key and value are well formed.
+func ValidateTag(key string, value string) error {
+	if !tagKeyPattern.MatchString(key) {
+		return fmt.Errorf("Invalid tag key %q", key)
+	}
+	if !tagValuePattern.MatchString(value) {
+		return fmt.Errorf("Invalid value %q for tag %s", value, key)
+	}
+	return nil
+}
+
+// ParseTags parses a list of key=value tag assignments.
+func ParseTags(assignments []string) (map[string]string, error) {
+	tags := make(map[string]string, len(assignments))
+	for _, a := range assignments {
+		kv := strings.SplitN(a, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("Invalid tag %q: expected key=value", a)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if err := ValidateTag(key, value); err != nil {
+			return nil, err
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+func (r requirement) matches(tags map[string]string) bool {
+	value, ok := tags[r.key]
+	switch r.op {
+	case opEquals:
+		return ok && value == r.values[0]
+	case opNotEquals:
+		return !ok || value != r.values[0]
+	case opIn:
+		return ok && contains(r.values, value)
+	case opNotIn:
+		return !ok || !contains(r.values, value)
+	case opExists:
+		return ok
+	case opNotExists:
+		return !ok
+	}
+	return false
+}
+
+// splitTerms splits a selector on the commas which are not inside a value set.
+func splitTerms(text string) ([]string, error) {
+	terms := make([]string, 0)
+	depth := 0
+	start := 0
+	for i, c := range text {
+		switch c {
+		case '(':
+			depth++
+			if depth > 1 {
+				return nil, fmt.Errorf("Invalid selector %q: nested parentheses", text)
+			}
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("Invalid selector %q: unbalanced parentheses", text)
+			}
+		case ',':
+			if depth == 0 {
+				terms = append(terms, strings.TrimSpace(text[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("Invalid selector %q: unbalanced parentheses", text)
+	}
+	terms = append(terms, strings.TrimSpace(text[start:]))
+	for _, term := range terms {
+		if term == "" {
+			return nil, fmt.Errorf("Invalid selector %q: empty requirement", text)
+		}
+	}
+	return terms, nil
+}
+
+func parseRequirement(term string) (requirement, error) {
+	var r requirement
+	if m := setPattern.FindStringSubmatch(term); m != nil {
+		r.key = m[1]
+		r.op = opIn
+		if m[2] == "notin" {
+			r.op = opNotIn
+		}
+		for _, v := range strings.Split(m[3], ",") {
+			v = strings.TrimSpace(v)
+			if err := ValidateTag(r.key, v); err != nil {
+				return r, err
+			}
+			r.values = append(r.values, v)
+		}
+		sort.Strings(r.values)
+		return r, nil
+	}
+
+	switch {
+	case strings.Contains(term, "!="):
+		kv := strings.SplitN(term, "!=", 2)
+		r.key, r.op, r.values = kv[0], opNotEquals, []string{kv[1]}
+	case strings.Contains(term, "=="):
+		kv := strings.SplitN(term, "==", 2)
+		r.key, r.op, r.values = kv[0], opEquals, []string{kv[1]}
+	case strings.Contains(term, "="):
+		kv := strings.SplitN(term, "=", 2)
+		r.key, r.op, r.values = kv[0], opEquals, []string{kv[1]}
+	case strings.HasPrefix(term, "!"):
+		r.key, r.op = term[1:], opNotExists
+	default:
+		r.key, r.op = term, opExists
+	}
+	r.key = strings.TrimSpace(r.key)
+	value := ""
+	if len(r.values) > 0 {
+		r.values[0] = strings.TrimSpace(r.values[0])
+		value = r.values[0]
+	}
+	if err := ValidateTag(r.key, value); err != nil {
+		return r, fmt.Errorf("Invalid selector requirement %q: %s", term, err)
+	}
+	return r, nil
+}
+
+func contains(values []string, value string) bool {
+	i := sort.SearchStrings(values, value)
+	return i < len(values) && values[i] == value
+}
@@ -0,0 +1,115 @@
+package selector
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	eu := map[string]string{"region": "eu", "tier": "core", "canary": ""}
+	us := map[string]string{"region": "us", "tier": "edge"}
+	empty := map[string]string{"region": ""}
+	none := map[string]string{}
+
+	tests := []struct {
+		selector string
+		// want lists whether the selector matches eu, us, empty and none, in that order.
+		want [4]bool
+	}{
+		{"", [4]bool{true, true, true, true}},
+		{"region=eu", [4]bool{true, false, false, false}},
+		{"region==eu", [4]bool{true, false, false, false}},
+		{" region = eu ", [4]bool{true, false, false, false}},
+		{"region!=eu", [4]bool{false, true, true, true}},
+		{"region=", [4]bool{false, false, true, false}},
+		{"region in (eu,us)", [4]bool{true, true, false, false}},
+		{"region in ( eu , us )", [4]bool{true, true, false, false}},
+		{"region notin (eu)", [4]bool{false, true, true, true}},
+		// An empty value set holds only the empty value.
+		{"region in ()", [4]bool{false, false, true, false}},
+		{"region notin ()", [4]bool{true, true, false, true}},
+		{"canary", [4]bool{true, false, false, false}},
+		{"!canary", [4]bool{false, true, true, true}},
+		{"region", [4]bool{true, true, true, false}},
+		{"region in (eu,us),tier=edge", [4]bool{false, true, false, false}},
+		{"tier notin (edge),!canary", [4]bool{false, false, true, true}},
+	}
+	for _, test := range tests {
+		t.Run(test.selector, func(t *testing.T) {
+			s, err := Parse(test.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, tags := range []map[string]string{eu, us, empty, none} {
+				if got := s.Matches(tags); got != test.want[i] {
+					t.Errorf("%q matches %v = %t, want %t", test.selector, tags, got, test.want[i])
+				}
+			}
+			if s.Empty() != (test.selector == "") {
+				t.Errorf("%q Empty() = %t", test.selector, s.Empty())
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"region=eu,",
+		",region=eu",
+		"region in (eu,(us))",
+		"region in (eu",
+		"region)",
+		"region=e u",
+		"-region=eu",
+		"region in (eu,-us)",
+		"re gion",
+		"!",
+	}
+	for _, selector := range tests {
+		t.Run(selector, func(t *testing.T) {
+			if s, err := Parse(selector); err == nil {
+				t.Errorf("Parse(%q) = %v, want an error", selector, s.requirements)
+			}
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		assignments []string
+		want        map[string]string
+		wantErr     bool
+	}{
+		{"none", nil, map[string]string{}, false},
+		{"several", []string{"region=eu", " tier = core "}, map[string]string{"region": "eu", "tier": "core"}, false},
+		{"empty value", []string{"canary="}, map[string]string{"canary": ""}, false},
+		{"key with prefix", []string{"example.com/team=ops"}, map[string]string{"example.com/team": "ops"}, false},
+		{"no value", []string{"region"}, nil, true},
+		{"invalid key", []string{"-region=eu"}, nil, true},
+		{"invalid value", []string{"region=e/u"}, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseTags(test.assignments)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("ParseTags(%q) = %v, want an error", test.assignments, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("ParseTags(%q) = %v, want %v", test.assignments, got, test.want)
+			}
+			for k, v := range test.want {
+				if got[k] != v {
+					t.Errorf("ParseTags(%q) = %v, want %v", test.assignments, got, test.want)
+				}
+			}
+		})
+	}
+}
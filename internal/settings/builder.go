@@ -5,8 +5,14 @@ package settings
 // Filecoin Retrieval Gateway Admin Client Settings
 
 import (
+	"net"
+	"os/user"
+	"strings"
+
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
 	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // BuilderImpl holds the library configuration
@@ -20,7 +26,44 @@ type BuilderImpl struct {
 
 	gatewayAdminPrivateKey    *fcrcrypto.KeyPair
 	gatewayAdminPrivateKeyVer *fcrcrypto.KeyVersion
-	registerURL string
+	registerURL               string
+
+	auditLogPath  string
+	auditOperator string
+
+	sensitiveLogFields      []string
+	unsafeUnredactedLogging bool
+
+	metricsRegisterer    prometheus.Registerer
+	metricsListenAddress string
+
+	tracerProvider trace.TracerProvider
+
+	resolver Resolver
+
+	tlsEnabled    bool
+	tlsCAFile     string
+	tlsCertFile   string
+	tlsKeyFile    string
+	tlsPinnedKeys []string
+
+	encryptGatewayKeys bool
+
+	allowLegacyResponses bool
+
+	inventoryPath string
+
+	transport Transport
+
+	dialer Dialer
+
+	gatewayDialers map[string]Dialer
+
+	connectionPool PoolConfig
+
+	keyPinPath string
+
+	signer Signer
 }
 
 // CreateSettings creates an object with the default settings.
@@ -30,6 +73,9 @@ func CreateSettings() *BuilderImpl {
 	f.logTarget = defaultLogTarget
 	f.logServiceName = defaultLogServiceName
 	f.establishmentTTL = defaultEstablishmentTTL
+	f.allowLegacyResponses = defaultAllowLegacyResponses
+	f.resolver = net.DefaultResolver
+	f.connectionPool = PoolConfig{}.WithDefaults()
 	return &f
 }
 
@@ -40,12 +86,13 @@ func (f *BuilderImpl) SetLogging(logLevel string, logTarget string, logServiceNa
 	f.logServiceName = logServiceName
 }
 
-// SetEstablishmentTTL sets the time to live for the establishment message between client and gateway.
+// SetEstablishmentTTL sets the time to live, in seconds, of admin requests. Requests carry an expiry
+// derived from it and responses which arrive after it are rejected.
 func (f *BuilderImpl) SetEstablishmentTTL(ttl int64) {
 	f.establishmentTTL = ttl
 }
 
-// SetBlockchainPrivateKey sets the blockchain private key.
+// SetBlockchainPrivateKey sets the blockchain private key. It is optional: admin operations do not use it.
 func (f *BuilderImpl) SetBlockchainPrivateKey(bcPkey *fcrcrypto.KeyPair) {
 	f.blockchainPrivateKey = bcPkey
 }
@@ -61,6 +108,116 @@ func (f *BuilderImpl) SetRegisterURL(regURL string) {
 	f.registerURL = regURL
 }
 
+// SetAuditLog sets the file the audit log is written to and the operator identity recorded against each entry.
+func (f *BuilderImpl) SetAuditLog(path string, operator string) {
+	f.auditLogPath = path
+	f.auditOperator = operator
+}
+
+// SetSensitiveLogFields sets extra message fields which are masked in log output.
+func (f *BuilderImpl) SetSensitiveLogFields(fields []string) {
+	f.sensitiveLogFields = fields
+}
+
+// SetUnsafeUnredactedLogging turns off masking of key material and signatures in log output.
+// This writes private keys to the logs and must only be used for local debugging.
+func (f *BuilderImpl) SetUnsafeUnredactedLogging(unsafe bool) {
+	f.unsafeUnredactedLogging = unsafe
+}
+
+// SetMetricsRegisterer sets the Prometheus registry the library's metrics are registered with.
+func (f *BuilderImpl) SetMetricsRegisterer(reg prometheus.Registerer) {
+	f.metricsRegisterer = reg
+}
+
+// SetMetricsListenAddress sets the address of the optional HTTP metrics endpoint. A registerer set with SetMetricsRegisterer must also be a prometheus.Gatherer for the endpoint to serve it.
+func (f *BuilderImpl) SetMetricsListenAddress(addr string) {
+	f.metricsListenAddress = addr
+}
+
+// SetTracerProvider sets the OpenTelemetry tracer provider used to trace admin operations.
+func (f *BuilderImpl) SetTracerProvider(tp trace.TracerProvider) {
+	f.tracerProvider = tp
+}
+
+// SetResolver sets the resolver used to look up the host names of gateways dialled directly, and of proxies and jump hosts.
+func (f *BuilderImpl) SetResolver(r Resolver) {
+	f.resolver = r
+}
+
+// SetTLS turns on TLS for the gateway admin HTTP and TCP channels. caFile is a PEM
+// bundle of the CAs which issue gateway certificates; empty means the system CAs.
+func (f *BuilderImpl) SetTLS(caFile string) {
+	f.tlsEnabled = true
+	f.tlsCAFile = caFile
+}
+
+// SetTLSClientCertificate sets the certificate presented to gateways for mutual TLS.
+func (f *BuilderImpl) SetTLSClientCertificate(certFile string, keyFile string) {
+	f.tlsCertFile = certFile
+	f.tlsKeyFile = keyFile
+}
+
+// SetTLSPinnedKeys sets the hex SHA-256 hashes of the gateway certificate public keys to accept.
+func (f *BuilderImpl) SetTLSPinnedKeys(pins []string) {
+	f.tlsPinnedKeys = pins
+}
+
+// SetEncryptGatewayKeys sets whether gateway private keys are encrypted to a gateway exchange key
+// during initialisation. The gateway must support the encrypted key exchange. TLS must be enabled, as
+// it is what authenticates the exchange key sent by the gateway.
+func (f *BuilderImpl) SetEncryptGatewayKeys(encrypt bool) {
+	f.encryptGatewayKeys = encrypt
+}
+
+// SetAllowLegacyResponses sets whether responses from gateways which do not echo the request nonce are accepted.
+// The default is false: responses which do not echo the nonce can be replayed.
+func (f *BuilderImpl) SetAllowLegacyResponses(allow bool) {
+	f.allowLegacyResponses = allow
+}
+
+// SetInventoryPath sets the file used to persist the inventory of managed gateways. If not set, the inventory is held in memory.
+func (f *BuilderImpl) SetInventoryPath(path string) {
+	f.inventoryPath = path
+}
+
+// SetTransport sets the transport admin messages are sent over. The default is the gateway admin TCP protocol. If TLS is enabled the transport must support TLS settings.
+func (f *BuilderImpl) SetTransport(t Transport) {
+	f.transport = t
+}
+
+// SetDialer sets the dialer used to connect to gateways which have no dialer of their own.
+func (f *BuilderImpl) SetDialer(d Dialer) {
+	f.dialer = d
+}
+
+// SetGatewayDialer sets the dialer used to connect to one gateway.
+func (f *BuilderImpl) SetGatewayDialer(nodeID string, d Dialer) {
+	if f.gatewayDialers == nil {
+		f.gatewayDialers = make(map[string]Dialer)
+	}
+	f.gatewayDialers[strings.ToLower(nodeID)] = d
+}
+
+// SetConnectionPool sets the idle connection limit, idle timeout and TCP keepalive of the admin connection pool.
+// An idle timeout shorter than MinPoolIdleTimeout is raised to it.
+func (f *BuilderImpl) SetConnectionPool(conf PoolConfig) {
+	if conf.IdleTimeout != 0 && conf.IdleTimeout < MinPoolIdleTimeout {
+		log.Warn("Settings: Connection pool idle timeout %s is too short, using %s", conf.IdleTimeout, MinPoolIdleTimeout)
+	}
+	f.connectionPool = conf.WithDefaults()
+}
+
+// SetKeyPinPath sets the file used to persist the signing keys pinned for each gateway. If not set, pins are held in memory.
+func (f *BuilderImpl) SetKeyPinPath(path string) {
+	f.keyPinPath = path
+}
+
+// SetSigner sets the signer used to sign admin messages, for example a remote signer holding the admin private key. The default signs in process with the admin private key.
+func (f *BuilderImpl) SetSigner(s Signer) {
+	f.signer = s
+}
+
 // Build creates a settings object and initialises the logging system.
 func (f *BuilderImpl) Build() *ClientGatewayAdminSettings {
 	log.Init1(f.logLevel, f.logTarget, f.logServiceName)
@@ -68,13 +225,59 @@ func (f *BuilderImpl) Build() *ClientGatewayAdminSettings {
 	g := ClientGatewayAdminSettings{}
 	g.establishmentTTL = f.establishmentTTL
 	g.registerURL = f.registerURL
-
-	if f.blockchainPrivateKey == nil {
-		log.ErrorAndPanic("Settings: Blockchain Private Key not set")
+	g.signer = f.signer
+	g.keyPinPath = f.keyPinPath
+	g.connectionPool = f.connectionPool
+	g.gatewayDialers = make(map[string]Dialer, len(f.gatewayDialers))
+	for nodeID, d := range f.gatewayDialers {
+		g.gatewayDialers[nodeID] = d
+	}
+	g.dialer = f.dialer
+	g.transport = f.transport
+	if f.transport != nil && f.tlsEnabled {
+		if _, ok := f.transport.(TLSTransport); !ok {
+			log.ErrorAndPanic("Settings: TLS is enabled but the transport does not support TLS settings")
+		}
 	}
+	g.inventoryPath = f.inventoryPath
+	g.auditLogPath = f.auditLogPath
+	g.auditOperator = f.auditOperator
+	g.allowLegacyResponses = f.allowLegacyResponses
+	g.encryptGatewayKeys = f.encryptGatewayKeys
+	g.resolver = f.resolver
+	g.tlsEnabled = f.tlsEnabled
+	g.tlsCAFile = f.tlsCAFile
+	g.tlsCertFile = f.tlsCertFile
+	g.tlsKeyFile = f.tlsKeyFile
+	g.tlsPinnedKeys = f.tlsPinnedKeys
+	if !g.tlsEnabled && (g.tlsCertFile != "" || len(g.tlsPinnedKeys) > 0) {
+		log.ErrorAndPanic("Settings: TLS client certificate or pinned keys set without enabling TLS")
+	}
+	if g.encryptGatewayKeys && !g.tlsEnabled {
+		log.ErrorAndPanic("Settings: Encrypted gateway keys need TLS to authenticate the gateway exchange key")
+	}
+	g.tracerProvider = f.tracerProvider
+	g.metricsRegisterer = f.metricsRegisterer
+	g.metricsListenAddress = f.metricsListenAddress
+	g.sensitiveLogFields = f.sensitiveLogFields
+	g.unsafeUnredactedLogging = f.unsafeUnredactedLogging
+	if g.unsafeUnredactedLogging {
+		log.Warn("Settings: UNSAFE unredacted logging enabled. Private keys and signatures will be written to the logs.")
+	}
+	if g.auditLogPath != "" && g.auditOperator == "" {
+		current, err := user.Current()
+		if err != nil {
+			log.ErrorAndPanic("Settings: Audit log operator not set and current user unknown: %s", err.Error())
+		}
+		g.auditOperator = current.Username
+	}
+
 	g.blockchainPrivateKey = f.blockchainPrivateKey
 
-	if f.gatewayAdminPrivateKey == nil {
+	if f.gatewayAdminPrivateKey == nil && f.signer != nil {
+		// The signer holds the admin key, so there is no key to generate.
+		g.gatewayAdminPrivateKeyVer = f.gatewayAdminPrivateKeyVer
+	} else if f.gatewayAdminPrivateKey == nil {
 		pKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
 		if err != nil {
 			log.ErrorAndPanic("Settings: Error while generating random retrieval key pair: %s" + err.Error())
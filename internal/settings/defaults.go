@@ -21,6 +21,20 @@ const (
 	// DefaultTCPInactivityTimeout is the default TCP timeout
 	DefaultTCPInactivityTimeout = 100 * time.Millisecond
 
+	// DefaultTCPDialTimeout is the default time allowed to establish a TCP connection to a gateway
+	DefaultTCPDialTimeout = 5 * time.Second
+
+	// DefaultPoolMaxIdlePerGateway is the default number of idle admin connections kept open to each gateway
+	DefaultPoolMaxIdlePerGateway = 2
+
+	// DefaultPoolIdleTimeout is the default time an idle admin connection is kept open
+	DefaultPoolIdleTimeout = 90 * time.Second
+
+	// MinPoolIdleTimeout is the shortest time an idle admin connection is kept open
+	MinPoolIdleTimeout = 10 * time.Millisecond
+
+	// DefaultTCPKeepAlive is the default TCP keepalive period of admin connections
+	DefaultTCPKeepAlive = 30 * time.Second
 
 	// DefaultEstablishmentTTL is the default Time To Live used with Client - Gateway estalishment messages.
 	defaultEstablishmentTTL = int64(100)
@@ -33,4 +47,8 @@ const (
 
 	// DefaultLogTarget is the default output location of log output.
 	defaultLogTarget = "STDOUT"
+
+	// defaultAllowLegacyResponses rejects responses from gateways which do not echo request nonces,
+	// as such responses can be replayed.
+	defaultAllowLegacyResponses = false
 )
@@ -0,0 +1,16 @@
+package settings
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client Settings
+
+import (
+	"context"
+	"net"
+)
+
+// Dialer opens connections to gateways, for example through a proxy or bastion host.
+// *net.Dialer implements this interface.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
@@ -5,7 +5,11 @@ package settings
 // Filecoin Retrieval Gateway Admin Client Settings
 
 import (
+	"strings"
+
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ClientGatewayAdminSettings holds the library configuration
@@ -18,6 +22,43 @@ type ClientGatewayAdminSettings struct {
 	gatewayAdminPrivateKeyVer *fcrcrypto.KeyVersion
 
 	registerURL string
+
+	auditLogPath  string
+	auditOperator string
+
+	sensitiveLogFields      []string
+	unsafeUnredactedLogging bool
+
+	metricsRegisterer    prometheus.Registerer
+	metricsListenAddress string
+
+	tracerProvider trace.TracerProvider
+
+	resolver Resolver
+
+	tlsEnabled    bool
+	tlsCAFile     string
+	tlsCertFile   string
+	tlsKeyFile    string
+	tlsPinnedKeys []string
+
+	encryptGatewayKeys bool
+
+	allowLegacyResponses bool
+
+	inventoryPath string
+
+	transport Transport
+
+	dialer Dialer
+
+	gatewayDialers map[string]Dialer
+
+	connectionPool PoolConfig
+
+	keyPinPath string
+
+	signer Signer
 }
 
 // EstablishmentTTL returns the establishmentTTL
@@ -25,7 +66,7 @@ func (c ClientGatewayAdminSettings) EstablishmentTTL() int64 {
 	return c.establishmentTTL
 }
 
-// BlockchainPrivateKey returns the BlockchainPrivateKey
+// BlockchainPrivateKey returns the BlockchainPrivateKey, or nil if it was not set
 func (c ClientGatewayAdminSettings) BlockchainPrivateKey() *fcrcrypto.KeyPair {
 	return c.blockchainPrivateKey
 }
@@ -43,4 +84,123 @@ func (c ClientGatewayAdminSettings) GatewayAdminPrivateKeyVer() *fcrcrypto.KeyVe
 // RegisterURL is the URL to the register service
 func (c ClientGatewayAdminSettings) RegisterURL() string {
 	return c.registerURL
-}
\ No newline at end of file
+}
+
+// AuditLogPath is the file the audit log is written to. Empty means auditing is disabled.
+func (c ClientGatewayAdminSettings) AuditLogPath() string {
+	return c.auditLogPath
+}
+
+// AuditOperator is the operator identity recorded in the audit log
+func (c ClientGatewayAdminSettings) AuditOperator() string {
+	return c.auditOperator
+}
+
+// SensitiveLogFields are the extra message fields masked in log output
+func (c ClientGatewayAdminSettings) SensitiveLogFields() []string {
+	return c.sensitiveLogFields
+}
+
+// UnsafeUnredactedLogging is true if key material is written to the logs unmasked
+func (c ClientGatewayAdminSettings) UnsafeUnredactedLogging() bool {
+	return c.unsafeUnredactedLogging
+}
+
+// MetricsRegisterer is the Prometheus registry metrics are registered with. Nil means a private registry.
+func (c ClientGatewayAdminSettings) MetricsRegisterer() prometheus.Registerer {
+	return c.metricsRegisterer
+}
+
+// MetricsListenAddress is the address of the HTTP metrics endpoint. Empty means no endpoint.
+func (c ClientGatewayAdminSettings) MetricsListenAddress() string {
+	return c.metricsListenAddress
+}
+
+// TracerProvider is the OpenTelemetry tracer provider. Nil means tracing is disabled.
+func (c ClientGatewayAdminSettings) TracerProvider() trace.TracerProvider {
+	return c.tracerProvider
+}
+
+// Resolver is used to look up gateway host names
+func (c ClientGatewayAdminSettings) Resolver() Resolver {
+	return c.resolver
+}
+
+// TLSEnabled is true if the gateway admin channels use TLS
+func (c ClientGatewayAdminSettings) TLSEnabled() bool {
+	return c.tlsEnabled
+}
+
+// TLSCAFile is the PEM bundle of CAs trusted to issue gateway certificates
+func (c ClientGatewayAdminSettings) TLSCAFile() string {
+	return c.tlsCAFile
+}
+
+// TLSClientCertFile is the client certificate used for mutual TLS
+func (c ClientGatewayAdminSettings) TLSClientCertFile() string {
+	return c.tlsCertFile
+}
+
+// TLSClientKeyFile is the private key of the client certificate used for mutual TLS
+func (c ClientGatewayAdminSettings) TLSClientKeyFile() string {
+	return c.tlsKeyFile
+}
+
+// TLSPinnedKeys are the hex SHA-256 hashes of accepted gateway certificate public keys
+func (c ClientGatewayAdminSettings) TLSPinnedKeys() []string {
+	return c.tlsPinnedKeys
+}
+
+// EncryptGatewayKeys is true if gateway private keys are encrypted end to end during initialisation
+func (c ClientGatewayAdminSettings) EncryptGatewayKeys() bool {
+	return c.encryptGatewayKeys
+}
+
+// AllowLegacyResponses is true if responses which do not echo the request nonce are accepted
+func (c ClientGatewayAdminSettings) AllowLegacyResponses() bool {
+	return c.allowLegacyResponses
+}
+
+// InventoryPath is the file used to persist the inventory of managed gateways
+func (c ClientGatewayAdminSettings) InventoryPath() string {
+	return c.inventoryPath
+}
+
+// Transport is the transport admin messages are sent over. Nil means the gateway admin TCP protocol.
+func (c ClientGatewayAdminSettings) Transport() Transport {
+	return c.transport
+}
+
+// Dialer is the dialer used to connect to gateways which have no dialer of their own. Nil means gateways are dialled directly.
+func (c ClientGatewayAdminSettings) Dialer() Dialer {
+	return c.dialer
+}
+
+// GatewayDialers are the dialers used to connect to individual gateways, by lower case node id.
+func (c ClientGatewayAdminSettings) GatewayDialers() map[string]Dialer {
+	return c.gatewayDialers
+}
+
+// DialerFor returns the dialer used to connect to a gateway: its own dialer if it has one,
+// otherwise the default dialer. Nil means the gateway is dialled directly.
+func (c ClientGatewayAdminSettings) DialerFor(nodeID string) Dialer {
+	if d, ok := c.gatewayDialers[strings.ToLower(nodeID)]; ok {
+		return d
+	}
+	return c.dialer
+}
+
+// ConnectionPool configures the pool of admin connections to gateways.
+func (c ClientGatewayAdminSettings) ConnectionPool() PoolConfig {
+	return c.connectionPool
+}
+
+// KeyPinPath is the file used to persist the signing keys pinned for each gateway
+func (c ClientGatewayAdminSettings) KeyPinPath() string {
+	return c.keyPinPath
+}
+
+// Signer is the signer used to sign admin messages, or nil to sign in process with the admin private key
+func (c ClientGatewayAdminSettings) Signer() Signer {
+	return c.signer
+}
@@ -0,0 +1,39 @@
+package settings
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client Settings
+
+import (
+	"time"
+)
+
+// PoolConfig configures the pool of admin connections to gateways. Zero fields take
+// the default values.
+type PoolConfig struct {
+	// MaxIdlePerGateway is the number of idle connections kept open to each gateway.
+	// Negative means connections are closed after each message.
+	MaxIdlePerGateway int
+	// IdleTimeout is how long an idle connection is kept open. Timeouts shorter than
+	// MinPoolIdleTimeout, including negative ones, are raised to it.
+	IdleTimeout time.Duration
+	// KeepAlive is the TCP keepalive period. Negative disables keepalive.
+	KeepAlive time.Duration
+}
+
+// WithDefaults returns the configuration with zero fields set to the default values, and
+// the idle timeout raised to MinPoolIdleTimeout if it is shorter.
+func (c PoolConfig) WithDefaults() PoolConfig {
+	if c.MaxIdlePerGateway == 0 {
+		c.MaxIdlePerGateway = DefaultPoolMaxIdlePerGateway
+	}
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = DefaultPoolIdleTimeout
+	} else if c.IdleTimeout < MinPoolIdleTimeout {
+		c.IdleTimeout = MinPoolIdleTimeout
+	}
+	if c.KeepAlive == 0 {
+		c.KeepAlive = DefaultTCPKeepAlive
+	}
+	return c
+}
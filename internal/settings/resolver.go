@@ -0,0 +1,15 @@
+package settings
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client Settings
+
+import (
+	"context"
+	"net"
+)
+
+// Resolver looks up the IP addresses of a host name. *net.Resolver implements this interface.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
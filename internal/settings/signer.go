@@ -0,0 +1,19 @@
+package settings
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client Settings
+
+import (
+	"context"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+// Signer signs admin messages with the admin private key, so the key need not be held by
+// the admin client itself. Implementations must be safe for concurrent use.
+type Signer interface {
+	// Sign returns the signature of a message, as produced by fcradminmessages.Sign: a
+	// signature of the message digest, which covers its type, protocol versions and body.
+	Sign(ctx context.Context, msg *fcrmessages.FCRMessage) (string, error)
+}
@@ -0,0 +1,39 @@
+package settings
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client Settings
+
+import (
+	"context"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/tlsconfig"
+)
+
+// Transport carries signed admin messages to gateways and returns their responses.
+// Implementations must be safe for concurrent use.
+type Transport interface {
+	// Exchange sends a signed request to a gateway and reads the gateway's response.
+	Exchange(ctx context.Context, gateway Endpoint, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error)
+	// Close releases any connections held by the transport.
+	Close() error
+}
+
+// TLSTransport is implemented by transports which can secure their connections to gateways
+// with the TLS settings: the trusted CAs, client certificate, pinned keys and node ID check.
+// A transport must implement it to be used when TLS is enabled.
+type TLSTransport interface {
+	// UseTLS sets the TLS configuration used for each gateway. It returns an error if the
+	// transport can not apply it.
+	UseTLS(b *tlsconfig.Builder) error
+}
+
+// Endpoint is the admin interface of a gateway.
+type Endpoint struct {
+	NodeID *nodeid.NodeID
+	// Address is the host and port of the gateway's admin interface.
+	Address string
+}
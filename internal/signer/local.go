@@ -0,0 +1,30 @@
+// Package signer provides the ways admin messages can be signed: in process with the admin
+// private key, or by a signing daemon holding the key, reached over a Unix socket.
+package signer
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"context"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+// Local signs messages in process with the admin private key.
+type Local struct {
+	key     *fcrcrypto.KeyPair
+	version *fcrcrypto.KeyVersion
+}
+
+// NewLocal creates a signer which signs with the given private key and key version.
+func NewLocal(key *fcrcrypto.KeyPair, version *fcrcrypto.KeyVersion) *Local {
+	return &Local{key: key, version: version}
+}
+
+// Sign signs the digest of a message with the private key.
+func (l *Local) Sign(ctx context.Context, msg *fcrmessages.FCRMessage) (string, error) {
+	return fcradminmessages.Sign(l.key, l.version, msg)
+}
@@ -0,0 +1,37 @@
+//go:build linux
+// +build linux
+
+package signer
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// checkUnixPeer checks that the process at the other end of a Unix socket runs as the same
+// user as the daemon. It returns the peer's user ID.
+func checkUnixPeer(conn *net.UnixConn) (int, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err == nil {
+		err = credErr
+	}
+	if err != nil {
+		return -1, fmt.Errorf("Unable to read the credentials of the signing client: %s", err)
+	}
+	if int(cred.Uid) != os.Getuid() {
+		return int(cred.Uid), fmt.Errorf("Signing client runs as user %d, not the daemon's user %d", cred.Uid, os.Getuid())
+	}
+	return int(cred.Uid), nil
+}
@@ -0,0 +1,113 @@
+package signer
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+// defaultTimeout bounds a signing request when the context has no deadline.
+const defaultTimeout = 10 * time.Second
+
+// minSignatureLength is the length of the hex encoded key version at the start of a signature.
+const minSignatureLength = 8
+
+// request asks a signing daemon to sign a message. Requests and responses are JSON
+// documents, one per line, and a connection may carry any number of them.
+type request struct {
+	Message *fcrmessages.FCRMessage `json:"message"`
+}
+
+// response is the signing daemon's reply to a request.
+type response struct {
+	Signature string `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Remote signs messages by sending them to a signing daemon, so the admin private key is
+// only held by the daemon.
+type Remote struct {
+	network string
+	address string
+	dialer  net.Dialer
+}
+
+// NewRemote creates a signer which uses the signing daemon at the given address: a Unix
+// socket path, optionally prefixed with unix://, or tcp://host:port for a daemon listening
+// on a loopback IP address. Other TCP addresses are refused.
+func NewRemote(address string) (*Remote, error) {
+	r := &Remote{network: "unix", address: address}
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		r.address = strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		r.network, r.address = "tcp", strings.TrimPrefix(address, "tcp://")
+		// Signing requests are not encrypted or authenticated, so they must not leave the host.
+		host, _, err := net.SplitHostPort(r.address)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid signer address %s: %s", address, err)
+		}
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return nil, fmt.Errorf("Signer address %s is not a loopback address, such as 127.0.0.1 or [::1]", address)
+		}
+	case strings.Contains(address, "://"):
+		return nil, fmt.Errorf("Unsupported signer address %s", address)
+	}
+	if r.address == "" {
+		return nil, fmt.Errorf("Signer address %s has no path or host", address)
+	}
+	return r, nil
+}
+
+// Sign sends a message to the signing daemon and returns the daemon's signature.
+func (r *Remote) Sign(ctx context.Context, msg *fcrmessages.FCRMessage) (string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+		defer cancel()
+	}
+	conn, err := r.dialer.DialContext(ctx, r.network, r.address)
+	if err != nil {
+		return "", fmt.Errorf("Unable to connect to signer %s: %s", r.address, err)
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return "", err
+	}
+
+	if err := json.NewEncoder(conn).Encode(request{Message: msg}); err != nil {
+		return "", fmt.Errorf("Error sending message to signer %s: %s", r.address, err)
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return "", fmt.Errorf("Error reading signature from signer %s: %s", r.address, err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("Signer %s refused to sign: %s", r.address, resp.Error)
+	}
+	if len(resp.Signature) < minSignatureLength {
+		return "", fmt.Errorf("Signer %s returned an invalid signature", r.address)
+	}
+	return resp.Signature, nil
+}
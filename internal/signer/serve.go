@@ -0,0 +1,143 @@
+package signer
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/audit"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcrkeyexchange"
+)
+
+// signOperation is the operation recorded in the audit log for each signing request.
+const signOperation = "sign"
+
+// signableTypes are the types of the admin challenges the daemon signs. Any other message,
+// such as a response which a gateway would send, is refused.
+var signableTypes = map[int32]bool{
+	fcrmessages.AdminGetReputationChallengeType:         true,
+	fcrmessages.AdminSetReputationChallengeType:         true,
+	fcrmessages.AdminAcceptKeyChallengeType:             true,
+	fcrkeyexchange.AdminGetExchangeKeyChallengeType:     true,
+	fcrkeyexchange.AdminAcceptEncryptedKeyChallengeType: true,
+	fcradminmessages.AdminListReputationsChallengeType:  true,
+	fcradminmessages.AdminEvictOffersChallengeType:      true,
+	fcradminmessages.AdminPinOffersChallengeType:        true,
+	fcradminmessages.AdminRefreshOffersChallengeType:    true,
+	fcradminmessages.AdminListOffersChallengeType:       true,
+}
+
+// Serve runs a signing daemon, signing the admin challenges sent by Remote signers
+// connecting to the listener with the given signer. Unix socket clients must run as the
+// daemon's user where the platform can tell, and TCP clients must connect from a loopback
+// address. Each request is recorded in auditLog, if it is not nil, and a signature is only
+// returned once it has been recorded. Serve returns when the listener is closed.
+func Serve(l net.Listener, s settings.Signer, auditLog *audit.Log) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Temporary() {
+				continue
+			}
+			return err
+		}
+		go serveConn(conn, s, auditLog)
+	}
+}
+
+func serveConn(conn net.Conn, s settings.Signer, auditLog *audit.Log) {
+	defer conn.Close()
+	peer, err := checkPeer(conn)
+	if err != nil {
+		log.Error("Refused signing client %s: %s", peer, err)
+		record(auditLog, map[string]string{"peer": peer}, err)
+		return
+	}
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			if err != io.EOF {
+				log.Error("Error reading signing request: %s", err)
+			}
+			return
+		}
+		if err := enc.Encode(sign(s, auditLog, peer, req.Message)); err != nil {
+			log.Error("Error writing signature: %s", err)
+			return
+		}
+	}
+}
+
+// sign signs a message for a client, if it is an admin challenge, and records the request.
+func sign(s settings.Signer, auditLog *audit.Log, peer string, msg *fcrmessages.FCRMessage) response {
+	params := map[string]string{"peer": peer}
+	var sig string
+	var err error
+	switch {
+	case msg == nil:
+		err = errors.New("No message to sign")
+	case !signableTypes[msg.MessageType]:
+		params["message_type"] = fmt.Sprintf("%d", msg.MessageType)
+		err = fmt.Errorf("Message type %d is not an admin challenge", msg.MessageType)
+	default:
+		msg.Signature = ""
+		params["message_type"] = fmt.Sprintf("%d", msg.MessageType)
+		params["digest"] = fcradminmessages.MessageDigest(msg)
+		sig, err = s.Sign(context.Background(), msg)
+	}
+	if auditErr := record(auditLog, params, err); auditErr != nil && err == nil {
+		sig, err = "", fmt.Errorf("Unable to record the signing request in the audit log: %s", auditErr)
+	}
+	if err != nil {
+		log.Warn("Refused to sign admin message for %s: %s", peer, err)
+		return response{Error: err.Error()}
+	}
+	log.Info("Signed admin message of type %d for %s", msg.MessageType, peer)
+	return response{Signature: sig}
+}
+
+// checkPeer checks that a signing client may use the daemon, returning a description of it
+// for the logs and the audit log.
+func checkPeer(conn net.Conn) (string, error) {
+	switch c := conn.(type) {
+	case *net.UnixConn:
+		uid, err := checkUnixPeer(c)
+		if uid < 0 {
+			return "unix", err
+		}
+		return fmt.Sprintf("unix uid %d", uid), err
+	case *net.TCPConn:
+		addr, ok := c.RemoteAddr().(*net.TCPAddr)
+		if !ok || !addr.IP.IsLoopback() {
+			return c.RemoteAddr().String(), errors.New("Signing client is not on a loopback address")
+		}
+		return addr.String(), nil
+	default:
+		return conn.RemoteAddr().String(), fmt.Errorf("Unsupported signing connection %T", conn)
+	}
+}
+
+// record appends an entry for a signing request to the audit log, if there is one.
+func record(auditLog *audit.Log, params map[string]string, opErr error) error {
+	if auditLog == nil {
+		return nil
+	}
+	err := auditLog.Record("", signOperation, params, opErr)
+	if err != nil {
+		log.Error("Error writing audit log entry: %s", err)
+	}
+	return err
+}
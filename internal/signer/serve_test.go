@@ -0,0 +1,95 @@
+package signer
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/audit"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+func TestServe(t *testing.T) {
+	key, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	auditPath := filepath.Join(dir, "audit.log")
+	auditLog, err := audit.Open(auditPath, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "signer.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go Serve(l, NewLocal(key, fcrcrypto.DecodeKeyVersion(1)), auditLog)
+	remote, err := NewRemote(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		messageType int32
+		wantSigned  bool
+	}{
+		{"admin challenge", fcradminmessages.AdminListOffersChallengeType, true},
+		{"set reputation challenge", fcrmessages.AdminSetReputationChallengeType, true},
+		{"gateway response", fcradminmessages.AdminListOffersResponseType, false},
+		{"client message", fcrmessages.ClientEstablishmentRequestType, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := &fcrmessages.FCRMessage{
+				MessageType:       test.messageType,
+				ProtocolVersion:   1,
+				ProtocolSupported: []int32{1, 1},
+				MessageBody:       []byte(`{"nonce":"aa","expiry":100}`),
+			}
+			sig, err := remote.Sign(context.Background(), msg)
+			if !test.wantSigned {
+				if err == nil {
+					t.Fatal("signed a message which is not an admin challenge")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg.Signature = sig
+			if ok, err := fcradminmessages.VerifyMessage(key, msg); err != nil || !ok {
+				t.Errorf("signature does not verify: %v", err)
+			}
+		})
+	}
+
+	entries, err := audit.ReadAll(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(tests) {
+		t.Fatalf("audit log has %d entries, want one per request", len(entries))
+	}
+	for i, e := range entries {
+		wantOutcome := audit.OutcomeFailure
+		if tests[i].wantSigned {
+			wantOutcome = audit.OutcomeSuccess
+		}
+		if e.Operation != signOperation || e.Outcome != wantOutcome || e.Params["peer"] == "" {
+			t.Errorf("audit entry %d is %+v, want a %s signing request with the peer", i, e, wantOutcome)
+		}
+	}
+	if err := audit.Verify(auditPath); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,141 @@
+package tlsconfig
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// NodeIDURIPrefix is the prefix of the URI SAN which carries a gateway's node ID.
+const NodeIDURIPrefix = "urn:fcr:gateway:"
+
+// Files holds the locations of the certificates used for TLS to gateways.
+type Files struct {
+	// CAFile is a PEM bundle of CAs trusted to issue gateway certificates. Empty means the system pool.
+	CAFile string
+	// CertFile and KeyFile are the admin client certificate for mutual TLS. Empty means no client certificate.
+	CertFile string
+	KeyFile  string
+	// PinnedKeys are hex SHA-256 hashes of gateway certificate public keys. Empty means no pinning.
+	PinnedKeys []string
+}
+
+// Builder creates TLS client configurations for individual gateways.
+type Builder struct {
+	roots        *x509.CertPool
+	certificates []tls.Certificate
+	pins         map[string]bool
+}
+
+// NewBuilder loads the CA bundle and client certificate.
+func NewBuilder(files Files) (*Builder, error) {
+	b := Builder{pins: make(map[string]bool)}
+	if files.CAFile != "" {
+		pem, err := ioutil.ReadFile(files.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		b.roots = x509.NewCertPool()
+		if !b.roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No certificates found in CA bundle %s", files.CAFile)
+		}
+	} else {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, err
+		}
+		b.roots = roots
+	}
+	if files.CertFile != "" || files.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		b.certificates = []tls.Certificate{cert}
+	}
+	for _, pin := range files.PinnedKeys {
+		b.pins[strings.ToLower(pin)] = true
+	}
+	return &b, nil
+}
+
+// ClientConfig returns a TLS configuration for connecting to the gateway with the
+// given node ID. The gateway certificate must chain to a trusted CA, must name the
+// node ID either as its common name or as a urn:fcr:gateway: URI, and, if pins are
+// configured, must have a pinned public key. Host names are not checked as gateways
+// are identified by node ID.
+func (b *Builder) ClientConfig(nodeID string) *tls.Config {
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: b.certificates,
+		// Verification is done in VerifyConnection against the node ID rather than the host name.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return b.verify(cs, nodeID)
+		},
+	}
+}
+
+func (b *Builder) verify(cs tls.ConnectionState, nodeID string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("Gateway presented no certificate")
+	}
+	leaf := cs.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         b.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return err
+	}
+	if !certificateNamesNode(leaf, nodeID) {
+		return fmt.Errorf("Gateway certificate does not belong to node %s", nodeID)
+	}
+	if len(b.pins) > 0 && !b.pins[PublicKeyPin(leaf)] {
+		return fmt.Errorf("Gateway certificate public key for node %s is not pinned", nodeID)
+	}
+	return nil
+}
+
+// PublicKeyPin returns the hex SHA-256 hash of the certificate's public key, as used for pinning.
+func PublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
+
+func certificateNamesNode(cert *x509.Certificate, nodeID string) bool {
+	if strings.EqualFold(cert.Subject.CommonName, nodeID) {
+		return true
+	}
+	for _, uri := range cert.URIs {
+		if strings.EqualFold(uri.String(), NodeIDURIPrefix+nodeID) {
+			return true
+		}
+	}
+	return false
+}
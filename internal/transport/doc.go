@@ -0,0 +1,6 @@
+// Package transport provides the ways admin messages can be carried to gateways: the
+// gateway admin TCP protocol, HTTP, and an in-memory transport for running gateways in
+// the same process.
+package transport
+
+// Copyright (C) 2020 ConsenSys Software Inc
@@ -0,0 +1,177 @@
+package transport
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/tlsconfig"
+)
+
+const (
+	httpAdminPath = "/v1/admin"
+	// maxResponseSize limits how much of a response body is read.
+	maxResponseSize = 16 * 1024 * 1024
+)
+
+// HTTP posts admin messages to the gateway's admin HTTP endpoint and reads the response
+// from the reply body.
+type HTTP struct {
+	client     *http.Client
+	scheme     string
+	propagator propagation.TextMapPropagator
+	// base is the client's transport, which is copied to use dialers and TLS settings. It is
+	// nil if the client does not use an *http.Transport.
+	base       *http.Transport
+	dialerFor  func(nodeID string) settings.Dialer
+	tlsBuilder *tlsconfig.Builder
+}
+
+// endpointKey is the context key of the gateway a request is being sent to.
+type endpointKey struct{}
+
+// NewHTTP creates an HTTP transport using client, which may be configured with a proxy or
+// TLS settings. A nil client uses a default client. If useTLS is true requests use HTTPS.
+func NewHTTP(client *http.Client, useTLS bool) *HTTP {
+	if client == nil {
+		client = &http.Client{Timeout: settings.DefaultTCPDialTimeout + responseTimeout}
+	}
+	t := HTTP{client: client, scheme: "http", propagator: propagation.TraceContext{}}
+	switch rt := client.Transport.(type) {
+	case nil:
+		t.base = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		t.base = rt
+	}
+	if useTLS {
+		t.scheme = "https"
+	}
+	return &t
+}
+
+// Exchange posts a request to the gateway and decodes the response.
+func (t *HTTP) Exchange(ctx context.Context, gateway settings.Endpoint, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+	body, err := fcrmessages.FCRMsgToBytes(request)
+	if err != nil {
+		return nil, err
+	}
+	apiURL := url.URL{Scheme: t.scheme, Host: gateway.Address, Path: httpAdminPath}
+	req, err := http.NewRequest("POST", apiURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(context.WithValue(ctx, endpointKey{}, gateway))
+	req.Header.Set("Content-Type", "application/json")
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("Gateway %s returned HTTP status %d", gateway.NodeID.ToString(), resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResponseSize {
+		return nil, fmt.Errorf("Response from gateway %s is larger than %d bytes", gateway.NodeID.ToString(), maxResponseSize)
+	}
+	return fcrmessages.FCRMsgFromBytes(data)
+}
+
+// UseDialers sets the function returning the dialer for each gateway. Gateways without a
+// dialer are dialled directly. Dialers can only be used if the client's transport is an
+// *http.Transport; it is copied rather than modified.
+func (t *HTTP) UseDialers(dialerFor func(nodeID string) settings.Dialer) {
+	if t.base == nil {
+		log.Warn("HTTP transport client does not use an *http.Transport: gateway dialers are not used")
+		return
+	}
+	t.dialerFor = dialerFor
+	t.configure()
+}
+
+// UseTLS makes requests use HTTPS, with the TLS configuration for each gateway built by b
+// rather than the client's. The client's transport must be an *http.Transport.
+func (t *HTTP) UseTLS(b *tlsconfig.Builder) error {
+	if t.base == nil {
+		return errors.New("HTTP transport client does not use an *http.Transport: TLS settings can not be applied")
+	}
+	t.scheme = "https"
+	t.tlsBuilder = b
+	t.configure()
+	return nil
+}
+
+// configure replaces the client's transport with a copy of the base transport which dials
+// through the gateway dialers and applies the TLS settings.
+func (t *HTTP) configure() {
+	transport := t.base.Clone()
+	transport.DialContext = t.dial
+	if t.tlsBuilder != nil {
+		transport.DialTLSContext = t.dialTLS
+	}
+	client := *t.client
+	client.Transport = transport
+	t.client = &client
+}
+
+// dial connects to the gateway a request is being sent to, using its dialer if it has one.
+func (t *HTTP) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	if gateway, ok := ctx.Value(endpointKey{}).(settings.Endpoint); ok && t.dialerFor != nil {
+		if d := t.dialerFor(gateway.NodeID.ToString()); d != nil {
+			return d.DialContext(ctx, network, address)
+		}
+	}
+	direct := &net.Dialer{Timeout: settings.DefaultTCPDialTimeout}
+	return direct.DialContext(ctx, network, address)
+}
+
+// dialTLS connects to the gateway a request is being sent to and completes a TLS handshake
+// checked against the gateway's node ID.
+func (t *HTTP) dialTLS(ctx context.Context, network, address string) (net.Conn, error) {
+	gateway, ok := ctx.Value(endpointKey{}).(settings.Endpoint)
+	if !ok {
+		return nil, fmt.Errorf("No gateway to check the TLS connection to %s against", address)
+	}
+	conn, err := t.dial(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, t.tlsBuilder.ClientConfig(gateway.NodeID.ToString()))
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(settings.DefaultTCPDialTimeout)
+	}
+	tlsConn.SetDeadline(deadline)
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	tlsConn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+// Close closes idle connections held by the client.
+func (t *HTTP) Close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
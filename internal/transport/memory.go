@@ -0,0 +1,79 @@
+package transport
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+)
+
+// Handler handles an admin message sent to an in-memory gateway, returning the response.
+type Handler func(ctx context.Context, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error)
+
+// Memory delivers admin messages to handlers in the same process. Messages are copied
+// through their wire encoding, so handlers and callers never share a message. Memory has
+// no connections to secure, so it can not be used with TLS enabled.
+type Memory struct {
+	lock     sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewMemory creates an in-memory transport with no gateways.
+func NewMemory() *Memory {
+	return &Memory{handlers: make(map[string]Handler)}
+}
+
+// Handle sets the handler for messages sent to a gateway, replacing any existing one.
+func (t *Memory) Handle(nodeID string, handler Handler) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.handlers[strings.ToLower(nodeID)] = handler
+}
+
+// Remove removes the handler for a gateway, making it unreachable.
+func (t *Memory) Remove(nodeID string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.handlers, strings.ToLower(nodeID))
+}
+
+// Exchange passes a request to the gateway's handler and returns its response.
+func (t *Memory) Exchange(ctx context.Context, gateway settings.Endpoint, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+	t.lock.RLock()
+	handler, ok := t.handlers[strings.ToLower(gateway.NodeID.ToString())]
+	t.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("No in-memory gateway %s", gateway.NodeID.ToString())
+	}
+	request, err := copyMessage(request)
+	if err != nil {
+		return nil, err
+	}
+	response, err := handler(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return copyMessage(response)
+}
+
+// Close removes all handlers.
+func (t *Memory) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.handlers = make(map[string]Handler)
+	return nil
+}
+
+func copyMessage(msg *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+	raw, err := fcrmessages.FCRMsgToBytes(msg)
+	if err != nil {
+		return nil, err
+	}
+	return fcrmessages.FCRMsgFromBytes(raw)
+}
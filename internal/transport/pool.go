@@ -0,0 +1,220 @@
+package transport
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+)
+
+// healthCheckTimeout is how long a health check waits for an idle connection to show it is closed.
+const healthCheckTimeout = time.Millisecond
+
+// PoolStats are the statistics of a pool of admin connections.
+type PoolStats struct {
+	// Open is the number of open connections, idle or in use.
+	Open  int
+	Idle  int
+	InUse int
+	// Dials is the number of connections opened.
+	Dials uint64
+	// Reuses is the number of times an idle connection was reused.
+	Reuses uint64
+	// Reconnects is the number of messages resent on a new connection after a reused
+	// connection was found to be broken.
+	Reconnects uint64
+	// HealthCheckFailures is the number of idle connections closed because they failed
+	// the health check before reuse.
+	HealthCheckFailures uint64
+	// IdleClosed is the number of idle connections closed because they timed out or
+	// exceeded the idle limit.
+	IdleClosed uint64
+}
+
+// pool holds idle admin connections to gateways. A connection is used by one exchange at
+// a time: it is taken from the pool for the exchange and returned afterwards.
+type pool struct {
+	config settings.PoolConfig
+	lock   sync.Mutex
+	idle   map[string][]idleConn
+	inUse  int
+	stats  PoolStats
+	closed bool
+	done   chan struct{}
+}
+
+type idleConn struct {
+	conn  net.Conn
+	since time.Time
+}
+
+func newPool(config settings.PoolConfig) *pool {
+	p := pool{
+		config: config.WithDefaults(),
+		idle:   make(map[string][]idleConn),
+		done:   make(chan struct{}),
+	}
+	go p.reap()
+	return &p
+}
+
+// get takes the most recently used healthy idle connection to a gateway, or returns nil
+// if there is none.
+func (p *pool) get(key string) net.Conn {
+	for {
+		p.lock.Lock()
+		conns := p.idle[key]
+		if len(conns) == 0 {
+			p.lock.Unlock()
+			return nil
+		}
+		c := conns[len(conns)-1]
+		p.setIdle(key, conns[:len(conns)-1])
+		if time.Since(c.since) > p.config.IdleTimeout {
+			p.stats.IdleClosed++
+			p.lock.Unlock()
+			c.conn.Close()
+			continue
+		}
+		p.lock.Unlock()
+
+		if !healthy(c.conn) {
+			c.conn.Close()
+			p.lock.Lock()
+			p.stats.HealthCheckFailures++
+			p.lock.Unlock()
+			continue
+		}
+		p.lock.Lock()
+		p.stats.Reuses++
+		p.inUse++
+		p.lock.Unlock()
+		return c.conn
+	}
+}
+
+// opened records a newly dialled connection, which is in use.
+func (p *pool) opened() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.stats.Dials++
+	p.inUse++
+}
+
+// reconnected records a message resent after a broken connection.
+func (p *pool) reconnected() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.stats.Reconnects++
+}
+
+// put returns a connection to the pool after a successful exchange.
+func (p *pool) put(key string, conn net.Conn) {
+	p.lock.Lock()
+	p.inUse--
+	conns := p.idle[key]
+	if p.closed || len(conns) >= p.config.MaxIdlePerGateway {
+		p.stats.IdleClosed++
+		p.lock.Unlock()
+		conn.Close()
+		return
+	}
+	p.idle[key] = append(conns, idleConn{conn: conn, since: time.Now()})
+	p.lock.Unlock()
+}
+
+// discard closes a connection which failed during an exchange.
+func (p *pool) discard(conn net.Conn) {
+	p.lock.Lock()
+	p.inUse--
+	p.lock.Unlock()
+	conn.Close()
+}
+
+// Stats returns the pool statistics.
+func (p *pool) Stats() PoolStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	stats := p.stats
+	for _, conns := range p.idle {
+		stats.Idle += len(conns)
+	}
+	stats.InUse = p.inUse
+	stats.Open = stats.Idle + stats.InUse
+	return stats
+}
+
+// close closes all idle connections. Connections in use are closed when they are returned.
+func (p *pool) close() {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.done)
+	idle := p.idle
+	p.idle = make(map[string][]idleConn)
+	p.lock.Unlock()
+	for _, conns := range idle {
+		for _, c := range conns {
+			c.conn.Close()
+		}
+	}
+}
+
+// reap closes idle connections which have timed out.
+func (p *pool) reap() {
+	ticker := time.NewTicker(p.config.IdleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+		expired := make([]net.Conn, 0)
+		p.lock.Lock()
+		for key, conns := range p.idle {
+			kept := conns[:0]
+			for _, c := range conns {
+				if time.Since(c.since) > p.config.IdleTimeout {
+					expired = append(expired, c.conn)
+				} else {
+					kept = append(kept, c)
+				}
+			}
+			p.setIdle(key, kept)
+		}
+		p.stats.IdleClosed += uint64(len(expired))
+		p.lock.Unlock()
+		for _, conn := range expired {
+			conn.Close()
+		}
+	}
+}
+
+// setIdle sets the idle connections of a gateway. The lock must be held.
+func (p *pool) setIdle(key string, conns []idleConn) {
+	if len(conns) == 0 {
+		delete(p.idle, key)
+		return
+	}
+	p.idle[key] = conns
+}
+
+// healthy returns false if an idle connection has been closed by the gateway, or has
+// data waiting which the gateway should not have sent.
+func healthy(conn net.Conn) bool {
+	if err := conn.SetReadDeadline(time.Now().Add(healthCheckTimeout)); err != nil {
+		return false
+	}
+	var b [1]byte
+	_, err := conn.Read(b[:])
+	conn.SetReadDeadline(time.Time{})
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
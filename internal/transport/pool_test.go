@@ -0,0 +1,121 @@
+package transport
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+)
+
+// newConn returns the client end of an in-memory connection and the gateway end.
+func newConn(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+	client, gateway := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		gateway.Close()
+	})
+	return client, gateway
+}
+
+func TestPoolReusesIdleConnection(t *testing.T) {
+	p := newPool(settings.PoolConfig{})
+	defer p.close()
+	conn, _ := newConn(t)
+	p.opened()
+	p.put("gw", conn)
+
+	if got := p.get("gw"); got != conn {
+		t.Fatalf("get returned %v, want the idle connection", got)
+	}
+	if got := p.get("gw"); got != nil {
+		t.Fatalf("get returned %v for a gateway with no idle connections", got)
+	}
+	stats := p.Stats()
+	if stats.Dials != 1 || stats.Reuses != 1 || stats.InUse != 1 || stats.Idle != 0 {
+		t.Errorf("stats are %+v, want 1 dial, 1 reuse and 1 connection in use", stats)
+	}
+}
+
+func TestPoolDiscardsClosedConnection(t *testing.T) {
+	p := newPool(settings.PoolConfig{})
+	defer p.close()
+	conn, gateway := newConn(t)
+	p.opened()
+	p.put("gw", conn)
+	gateway.Close()
+
+	if got := p.get("gw"); got != nil {
+		t.Fatal("get returned a connection the gateway has closed")
+	}
+	stats := p.Stats()
+	if stats.HealthCheckFailures != 1 || stats.Reuses != 0 || stats.Open != 0 {
+		t.Errorf("stats are %+v, want 1 health check failure and no open connections", stats)
+	}
+}
+
+func TestPoolLimitsIdleConnections(t *testing.T) {
+	p := newPool(settings.PoolConfig{MaxIdlePerGateway: 1})
+	defer p.close()
+	first, _ := newConn(t)
+	second, _ := newConn(t)
+	p.opened()
+	p.opened()
+	p.put("gw", first)
+	p.put("gw", second)
+
+	stats := p.Stats()
+	if stats.Idle != 1 || stats.IdleClosed != 1 {
+		t.Errorf("stats are %+v, want 1 idle connection and 1 closed", stats)
+	}
+}
+
+func TestPoolReapsIdleConnections(t *testing.T) {
+	tests := []struct {
+		name        string
+		idleTimeout time.Duration
+	}{
+		{"minimum", settings.MinPoolIdleTimeout},
+		{"one nanosecond", time.Nanosecond},
+		{"negative", -time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newPool(settings.PoolConfig{IdleTimeout: test.idleTimeout})
+			defer p.close()
+			conn, _ := newConn(t)
+			p.opened()
+			p.put("gw", conn)
+
+			deadline := time.Now().Add(time.Second)
+			for p.Stats().Idle != 0 {
+				if time.Now().After(deadline) {
+					t.Fatalf("idle connection was not reaped: %+v", p.Stats())
+				}
+				time.Sleep(settings.MinPoolIdleTimeout)
+			}
+			if stats := p.Stats(); stats.IdleClosed != 1 {
+				t.Errorf("stats are %+v, want 1 idle connection closed", stats)
+			}
+		})
+	}
+}
+
+func TestPoolCloseClosesIdleConnections(t *testing.T) {
+	p := newPool(settings.PoolConfig{})
+	conn, gateway := newConn(t)
+	p.opened()
+	p.put("gw", conn)
+	p.close()
+	p.close()
+
+	if _, err := gateway.Write([]byte{0}); err == nil {
+		t.Error("idle connection is still open after the pool was closed")
+	}
+	if got := p.get("gw"); got != nil {
+		t.Error("get returned a connection from a closed pool")
+	}
+}
@@ -0,0 +1,173 @@
+package transport
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrtcpcomms"
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/tlsconfig"
+)
+
+// responseTimeout is how long to wait for a gateway to respond to an admin message.
+const responseTimeout = time.Second * 1
+
+// TCP sends admin messages over the gateway admin TCP protocol, keeping a pool of idle
+// connections to each gateway.
+type TCP struct {
+	pool        *pool
+	tlsBuilder  *tlsconfig.Builder
+	dialerFor   func(nodeID string) settings.Dialer
+	onReconnect func(ctx context.Context, gateway string)
+}
+
+// NewTCP creates a TCP transport. If tlsBuilder is not nil connections use TLS.
+func NewTCP(tlsBuilder *tlsconfig.Builder, poolConfig settings.PoolConfig) *TCP {
+	return &TCP{pool: newPool(poolConfig), tlsBuilder: tlsBuilder}
+}
+
+// Exchange sends a request over a connection to the gateway and reads the response. Idle
+// connections are health checked before they are reused. If writing the request to a reused
+// connection fails because the gateway has closed it, the request is sent again over a new
+// connection. Once a request has been written it is never resent, as the gateway may have
+// acted on it.
+func (t *TCP) Exchange(ctx context.Context, gateway settings.Endpoint, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+	key := strings.ToLower(gateway.NodeID.ToString())
+	conn := t.pool.get(key)
+	reused := conn != nil
+	if !reused {
+		var err error
+		conn, err = t.dial(ctx, gateway)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response, sent, err := exchangeTCP(conn, request)
+	if err != nil && !sent && reused && isBrokenConnection(err) {
+		log.Warn("Connection to gateway %v was broken, reconnecting: %s", gateway.NodeID.ToString(), err)
+		t.pool.discard(conn)
+		t.pool.reconnected()
+		if t.onReconnect != nil {
+			t.onReconnect(ctx, gateway.NodeID.ToString())
+		}
+		conn, err = t.dial(ctx, gateway)
+		if err != nil {
+			return nil, err
+		}
+		response, _, err = exchangeTCP(conn, request)
+	}
+	if err != nil {
+		t.pool.discard(conn)
+		return nil, err
+	}
+	t.pool.put(key, conn)
+	return response, nil
+}
+
+// UseTLS makes connections use TLS, configured for each gateway by b.
+func (t *TCP) UseTLS(b *tlsconfig.Builder) error {
+	t.tlsBuilder = b
+	return nil
+}
+
+// UseDialers sets the function returning the dialer for each gateway. Gateways without
+// a dialer are dialled directly.
+func (t *TCP) UseDialers(dialerFor func(nodeID string) settings.Dialer) {
+	t.dialerFor = dialerFor
+}
+
+// OnReconnect sets a function called when a request is resent after a broken connection.
+func (t *TCP) OnReconnect(fn func(ctx context.Context, gateway string)) {
+	t.onReconnect = fn
+}
+
+// Connections returns the number of open gateway connections.
+func (t *TCP) Connections() int {
+	return t.pool.Stats().Open
+}
+
+// PoolStats returns the connection pool statistics.
+func (t *TCP) PoolStats() PoolStats {
+	return t.pool.Stats()
+}
+
+// Close closes all idle gateway connections.
+func (t *TCP) Close() error {
+	t.pool.close()
+	return nil
+}
+
+// exchangeTCP sends a request over a connection and reads the response. sent is true if
+// the request was written, even if no response was read.
+func exchangeTCP(conn net.Conn, request *fcrmessages.FCRMessage) (response *fcrmessages.FCRMessage, sent bool, err error) {
+	err = fcrtcpcomms.SendTCPMessage(conn, request, settings.DefaultTCPInactivityTimeout)
+	if err != nil {
+		log.Error("Error sending message to Gateway: %s", err)
+		return nil, false, err
+	}
+	response, err = fcrtcpcomms.ReadTCPMessage(conn, responseTimeout)
+	if err != nil {
+		log.Error("Error reading response from Gateway: %s", err)
+		return nil, true, err
+	}
+	return response, true, nil
+}
+
+// dial opens a new connection to a gateway, using its dialer if it has one and TLS if it
+// is enabled.
+func (t *TCP) dial(ctx context.Context, gateway settings.Endpoint) (net.Conn, error) {
+	nodeID := gateway.NodeID
+	keepAlive := t.pool.config.KeepAlive
+	var d settings.Dialer
+	if t.dialerFor != nil {
+		d = t.dialerFor(nodeID.ToString())
+	}
+	if d == nil {
+		d = &net.Dialer{KeepAlive: keepAlive}
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, settings.DefaultTCPDialTimeout)
+	defer cancel()
+	conn, err := d.DialContext(dialCtx, "tcp", gateway.Address)
+	if err != nil {
+		log.Error("Error getting a connection to gateway %v: %s", nodeID.ToString(), err)
+		return nil, err
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetKeepAlive(keepAlive > 0)
+		if keepAlive > 0 {
+			tcpConn.SetKeepAlivePeriod(keepAlive)
+		}
+	}
+	if t.tlsBuilder != nil {
+		tlsConn := tls.Client(conn, t.tlsBuilder.ClientConfig(nodeID.ToString()))
+		tlsConn.SetDeadline(time.Now().Add(settings.DefaultTCPDialTimeout))
+		err = tlsConn.Handshake()
+		if err != nil {
+			log.Error("Error getting a TLS connection to gateway %v: %s", nodeID.ToString(), err)
+			conn.Close()
+			return nil, err
+		}
+		tlsConn.SetDeadline(time.Time{})
+		conn = tlsConn
+	}
+	t.pool.opened()
+	return conn, nil
+}
+
+// isBrokenConnection returns true for errors showing the gateway closed the connection.
+func isBrokenConnection(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
@@ -0,0 +1,35 @@
+// Package wire encodes and decodes the JSON bodies of the admin messages defined outside
+// fcrmessages.
+package wire
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+// Encode returns an FCRMessage of msgType with body as its JSON message body.
+func Encode(msgType int32, body interface{}) (*fcrmessages.FCRMessage, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	protocolVersion, protocolSupported := fcrmessages.GetProtocolVersion()
+	return &fcrmessages.FCRMessage{
+		MessageType:       msgType,
+		ProtocolVersion:   protocolVersion,
+		ProtocolSupported: protocolSupported,
+		MessageBody:       raw,
+	}, nil
+}
+
+// Decode checks fcrMsg is of msgType and unmarshals its message body into msg.
+func Decode(fcrMsg *fcrmessages.FCRMessage, msgType int32, msg interface{}) error {
+	if fcrMsg.MessageType != msgType {
+		return fmt.Errorf("Message type mismatch")
+	}
+	return json.Unmarshal(fcrMsg.MessageBody, msg)
+}
@@ -0,0 +1,6 @@
+// Package fcradminmessages defines the admin protocol messages which are not yet part of
+// fcrmessages. Message types carry on from the admin types there and the encrypted key
+// exchange types in fcrkeyexchange.
+package fcradminmessages
+
+// Copyright (C) 2020 ConsenSys Software Inc
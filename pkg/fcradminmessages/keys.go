@@ -0,0 +1,73 @@
+package fcradminmessages
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"fmt"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/wire"
+)
+
+// KeyRejectionReason is the reason code a gateway gives for not accepting a private key.
+type KeyRejectionReason int32
+
+// Key rejection reasons. Gateways which predate reason codes send none, which decodes as
+// KeyRejectionUnspecified.
+const (
+	KeyRejectionUnspecified        KeyRejectionReason = 0
+	KeyRejectionAlreadyInitialized KeyRejectionReason = 1
+	KeyRejectionBadKeyVersion      KeyRejectionReason = 2
+	KeyRejectionDecodeFailure      KeyRejectionReason = 3
+	KeyRejectionUnauthorizedAdmin  KeyRejectionReason = 4
+)
+
+func (r KeyRejectionReason) String() string {
+	switch r {
+	case KeyRejectionUnspecified:
+		return "unspecified reason"
+	case KeyRejectionAlreadyInitialized:
+		return "gateway already initialized"
+	case KeyRejectionBadKeyVersion:
+		return "bad key version"
+	case KeyRejectionDecodeFailure:
+		return "key could not be decoded"
+	case KeyRejectionUnauthorizedAdmin:
+		return "admin not authorized"
+	}
+	return fmt.Sprintf("reason code %d", int32(r))
+}
+
+// AdminAcceptKeyResponseWithReason is fcrmessages.AdminAcceptKeyResponse with the reason a
+// gateway did not accept the key.
+type AdminAcceptKeyResponseWithReason struct {
+	fcrmessages.AdminAcceptKeyResponse
+	AdminAcceptKeyResponseExtension
+}
+
+// AdminAcceptKeyResponseExtension holds the fields which gateways reporting rejection reasons
+// add to fcrmessages.AdminAcceptKeyResponse.
+type AdminAcceptKeyResponseExtension struct {
+	Reason  KeyRejectionReason `json:"reason,omitempty"`
+	Message string             `json:"reason_message,omitempty"`
+}
+
+// EncodeAdminAcceptKeyResponseWithReason is used to get the FCRMessage of an
+// fcrmessages.AdminAcceptKeyResponse which carries a rejection reason.
+func EncodeAdminAcceptKeyResponseWithReason(exists bool, reason KeyRejectionReason, message string) (*fcrmessages.FCRMessage, error) {
+	return wire.Encode(fcrmessages.AdminAcceptKeyResponseType, AdminAcceptKeyResponseWithReason{
+		fcrmessages.AdminAcceptKeyResponse{Exists: exists},
+		AdminAcceptKeyResponseExtension{reason, message},
+	})
+}
+
+// DecodeAdminAcceptKeyResponseExtension is used to get the optional rejection reason from
+// FCRMessage of fcrmessages.AdminAcceptKeyResponse. It is zero if the gateway does not send one.
+func DecodeAdminAcceptKeyResponseExtension(fcrMsg *fcrmessages.FCRMessage) (*AdminAcceptKeyResponseExtension, error) {
+	msg := AdminAcceptKeyResponseExtension{}
+	if err := wire.Decode(fcrMsg, fcrmessages.AdminAcceptKeyResponseType, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
@@ -0,0 +1,15 @@
+package fcradminmessages
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Message types.
+const (
+	AdminListReputationsChallengeType = 209
+	AdminListReputationsResponseType  = 210
+	AdminEvictOffersChallengeType     = 211
+	AdminPinOffersChallengeType       = 212
+	AdminRefreshOffersChallengeType   = 213
+	AdminOfferAckType                 = 214
+	AdminListOffersChallengeType      = 215
+	AdminListOffersResponseType       = 216
+)
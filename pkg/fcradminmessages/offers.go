@@ -0,0 +1,179 @@
+package fcradminmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/cidoffer"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/wire"
+)
+
+// AdminEvictOffersChallenge is the request from an admin client to a gateway to remove offers
+// from its cache. Offers are identified by provider and merkle root; if MerkleRoots is empty
+// all of the provider's offers are removed.
+type AdminEvictOffersChallenge struct {
+	ProviderID  nodeid.NodeID `json:"provider_id"`
+	MerkleRoots []string      `json:"merkle_roots,omitempty"`
+}
+
+// AdminPinOffersChallenge is the request from an admin client to a gateway to keep offers in
+// its cache past their expiry, or to stop doing so if Pinned is false. If MerkleRoots is empty
+// all of the provider's offers are pinned or unpinned.
+type AdminPinOffersChallenge struct {
+	ProviderID  nodeid.NodeID `json:"provider_id"`
+	MerkleRoots []string      `json:"merkle_roots,omitempty"`
+	Pinned      bool          `json:"pinned"`
+}
+
+// AdminRefreshOffersChallenge is the request from an admin client to a gateway to fetch a
+// provider's current offers from the provider.
+type AdminRefreshOffersChallenge struct {
+	ProviderID nodeid.NodeID `json:"provider_id"`
+}
+
+// AdminOfferAck is the response to the offer challenges. Challenge is the message type of the
+// challenge acknowledged, and MerkleRoots the offers it affected.
+type AdminOfferAck struct {
+	Challenge   int32         `json:"challenge"`
+	ProviderID  nodeid.NodeID `json:"provider_id"`
+	Accepted    bool          `json:"accepted"`
+	Reason      string        `json:"reason,omitempty"`
+	MerkleRoots []string      `json:"merkle_roots,omitempty"`
+}
+
+// AdminListOffersChallenge is the request from an admin client to a gateway for a page of the
+// offers in its cache. Cursor is empty for the first page, and otherwise the NextCursor of the
+// previous page.
+type AdminListOffersChallenge struct {
+	Cursor string `json:"cursor,omitempty"`
+	Limit  int32  `json:"limit"`
+}
+
+// AdminListOffersResponse is the response to AdminListOffersChallenge. NextCursor is empty on
+// the last page.
+type AdminListOffersResponse struct {
+	Offers     []Offer `json:"offers"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// Offer is a CID group offer as held by a gateway, with the provider's signature.
+type Offer struct {
+	ProviderID nodeid.NodeID   `json:"provider_id"`
+	Cids       []cid.ContentID `json:"cids"`
+	Price      uint64          `json:"price_per_byte"`
+	Expiry     int64           `json:"expiry_date"`
+	QoS        uint64          `json:"qos"`
+	MerkleRoot string          `json:"merkle_root"`
+	Signature  string          `json:"signature"`
+}
+
+// SigningFields returns the fields of the offer covered by the provider's signature.
+func (o *Offer) SigningFields() cidoffer.CidGroupOfferSigning {
+	return cidoffer.CidGroupOfferSigning{
+		NodeID:     o.ProviderID,
+		Price:      o.Price,
+		Expiry:     o.Expiry,
+		QoS:        o.QoS,
+		MerkleRoot: o.MerkleRoot,
+	}
+}
+
+// EncodeAdminEvictOffersChallenge is used to get the FCRMessage of AdminEvictOffersChallenge
+func EncodeAdminEvictOffersChallenge(providerID *nodeid.NodeID, merkleRoots []string) (*fcrmessages.FCRMessage, error) {
+	return wire.Encode(AdminEvictOffersChallengeType, AdminEvictOffersChallenge{*providerID, merkleRoots})
+}
+
+// DecodeAdminEvictOffersChallenge is used to get the fields from FCRMessage of AdminEvictOffersChallenge
+func DecodeAdminEvictOffersChallenge(fcrMsg *fcrmessages.FCRMessage) (*nodeid.NodeID, []string, error) {
+	msg := AdminEvictOffersChallenge{}
+	if err := wire.Decode(fcrMsg, AdminEvictOffersChallengeType, &msg); err != nil {
+		return nil, nil, err
+	}
+	return &msg.ProviderID, msg.MerkleRoots, nil
+}
+
+// EncodeAdminPinOffersChallenge is used to get the FCRMessage of AdminPinOffersChallenge
+func EncodeAdminPinOffersChallenge(providerID *nodeid.NodeID, merkleRoots []string, pinned bool) (*fcrmessages.FCRMessage, error) {
+	return wire.Encode(AdminPinOffersChallengeType, AdminPinOffersChallenge{*providerID, merkleRoots, pinned})
+}
+
+// DecodeAdminPinOffersChallenge is used to get the fields from FCRMessage of AdminPinOffersChallenge
+func DecodeAdminPinOffersChallenge(fcrMsg *fcrmessages.FCRMessage) (*nodeid.NodeID, []string, bool, error) {
+	msg := AdminPinOffersChallenge{}
+	if err := wire.Decode(fcrMsg, AdminPinOffersChallengeType, &msg); err != nil {
+		return nil, nil, false, err
+	}
+	return &msg.ProviderID, msg.MerkleRoots, msg.Pinned, nil
+}
+
+// EncodeAdminRefreshOffersChallenge is used to get the FCRMessage of AdminRefreshOffersChallenge
+func EncodeAdminRefreshOffersChallenge(providerID *nodeid.NodeID) (*fcrmessages.FCRMessage, error) {
+	return wire.Encode(AdminRefreshOffersChallengeType, AdminRefreshOffersChallenge{*providerID})
+}
+
+// DecodeAdminRefreshOffersChallenge is used to get the fields from FCRMessage of AdminRefreshOffersChallenge
+func DecodeAdminRefreshOffersChallenge(fcrMsg *fcrmessages.FCRMessage) (*nodeid.NodeID, error) {
+	msg := AdminRefreshOffersChallenge{}
+	if err := wire.Decode(fcrMsg, AdminRefreshOffersChallengeType, &msg); err != nil {
+		return nil, err
+	}
+	return &msg.ProviderID, nil
+}
+
+// EncodeAdminOfferAck is used to get the FCRMessage of AdminOfferAck
+func EncodeAdminOfferAck(ack *AdminOfferAck) (*fcrmessages.FCRMessage, error) {
+	return wire.Encode(AdminOfferAckType, ack)
+}
+
+// DecodeAdminOfferAck is used to get the fields from FCRMessage of AdminOfferAck
+func DecodeAdminOfferAck(fcrMsg *fcrmessages.FCRMessage) (*AdminOfferAck, error) {
+	msg := AdminOfferAck{}
+	if err := wire.Decode(fcrMsg, AdminOfferAckType, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// EncodeAdminListOffersChallenge is used to get the FCRMessage of AdminListOffersChallenge
+func EncodeAdminListOffersChallenge(cursor string, limit int32) (*fcrmessages.FCRMessage, error) {
+	return wire.Encode(AdminListOffersChallengeType, AdminListOffersChallenge{cursor, limit})
+}
+
+// DecodeAdminListOffersChallenge is used to get the fields from FCRMessage of AdminListOffersChallenge
+func DecodeAdminListOffersChallenge(fcrMsg *fcrmessages.FCRMessage) (string, int32, error) {
+	msg := AdminListOffersChallenge{}
+	if err := wire.Decode(fcrMsg, AdminListOffersChallengeType, &msg); err != nil {
+		return "", 0, err
+	}
+	return msg.Cursor, msg.Limit, nil
+}
+
+// EncodeAdminListOffersResponse is used to get the FCRMessage of AdminListOffersResponse
+func EncodeAdminListOffersResponse(offers []Offer, nextCursor string) (*fcrmessages.FCRMessage, error) {
+	return wire.Encode(AdminListOffersResponseType, AdminListOffersResponse{offers, nextCursor})
+}
+
+// DecodeAdminListOffersResponse is used to get the fields from FCRMessage of AdminListOffersResponse
+func DecodeAdminListOffersResponse(fcrMsg *fcrmessages.FCRMessage) ([]Offer, string, error) {
+	msg := AdminListOffersResponse{}
+	if err := wire.Decode(fcrMsg, AdminListOffersResponseType, &msg); err != nil {
+		return nil, "", err
+	}
+	return msg.Offers, msg.NextCursor, nil
+}
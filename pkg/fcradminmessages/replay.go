@@ -0,0 +1,22 @@
+package fcradminmessages
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/replay"
+)
+
+// ReplayGuard rejects expired and replayed admin requests on a gateway, and echoes their
+// nonces in the responses, which admin clients require.
+type ReplayGuard = replay.Guard
+
+// ReplayStamp is the nonce and expiry of an admin request.
+type ReplayStamp = replay.Stamp
+
+// NewReplayGuard creates a guard for a gateway. Requests which expire more than maxTTL in the
+// future are rejected, as their nonces would need to be remembered for longer.
+func NewReplayGuard(maxTTL time.Duration) *ReplayGuard {
+	return replay.NewGuard(maxTTL, false)
+}
@@ -0,0 +1,123 @@
+package fcradminmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/wire"
+)
+
+// ClientReputation is the reputation a gateway holds for one client. LastChanged and
+// History are only returned by gateways which record them.
+type ClientReputation struct {
+	ClientID    nodeid.NodeID      `json:"clientid"`
+	Reputation  int64              `json:"reputation"`
+	LastChanged int64              `json:"last_changed,omitempty"`
+	History     []ReputationChange `json:"history,omitempty"`
+}
+
+// ReputationChange is a past change to a client's reputation. Time is in seconds since the epoch.
+type ReputationChange struct {
+	Time       int64 `json:"time"`
+	Reputation int64 `json:"reputation"`
+}
+
+// ReputationFilter selects the client reputations to list. Cursor is empty for the first
+// page, and otherwise the NextCursor of the previous page. ChangedSince is in seconds since
+// the epoch. Zero or nil fields do not filter.
+type ReputationFilter struct {
+	Cursor         string `json:"cursor,omitempty"`
+	Limit          int32  `json:"limit"`
+	MinReputation  *int64 `json:"min_reputation,omitempty"`
+	MaxReputation  *int64 `json:"max_reputation,omitempty"`
+	ChangedSince   int64  `json:"changed_since,omitempty"`
+	IncludeHistory bool   `json:"include_history,omitempty"`
+}
+
+// AdminListReputationsChallenge is the request from an admin client to a gateway for a page of
+// client reputations, in client ID order.
+type AdminListReputationsChallenge struct {
+	ReputationFilter
+}
+
+// AdminListReputationsResponse is the response to AdminListReputationsChallenge. NextCursor is
+// empty on the last page.
+type AdminListReputationsResponse struct {
+	Reputations []ClientReputation `json:"reputations"`
+	NextCursor  string             `json:"next_cursor,omitempty"`
+}
+
+// AdminGetReputationResponseExtension holds the fields which gateways recording reputation
+// history add to fcrmessages.AdminGetReputationResponse.
+type AdminGetReputationResponseExtension struct {
+	LastChanged int64              `json:"last_changed,omitempty"`
+	History     []ReputationChange `json:"history,omitempty"`
+}
+
+// Matches returns true if the reputation is selected by the filter's value and time criteria.
+func (f *ReputationFilter) Matches(r *ClientReputation) bool {
+	if f.MinReputation != nil && r.Reputation < *f.MinReputation {
+		return false
+	}
+	if f.MaxReputation != nil && r.Reputation > *f.MaxReputation {
+		return false
+	}
+	if f.ChangedSince != 0 && r.LastChanged != 0 && r.LastChanged < f.ChangedSince {
+		return false
+	}
+	return true
+}
+
+// EncodeAdminListReputationsChallenge is used to get the FCRMessage of AdminListReputationsChallenge
+func EncodeAdminListReputationsChallenge(filter *ReputationFilter) (*fcrmessages.FCRMessage, error) {
+	return wire.Encode(AdminListReputationsChallengeType, AdminListReputationsChallenge{*filter})
+}
+
+// DecodeAdminListReputationsChallenge is used to get the fields from FCRMessage of AdminListReputationsChallenge
+func DecodeAdminListReputationsChallenge(fcrMsg *fcrmessages.FCRMessage) (*ReputationFilter, error) {
+	msg := AdminListReputationsChallenge{}
+	if err := wire.Decode(fcrMsg, AdminListReputationsChallengeType, &msg); err != nil {
+		return nil, err
+	}
+	return &msg.ReputationFilter, nil
+}
+
+// EncodeAdminListReputationsResponse is used to get the FCRMessage of AdminListReputationsResponse
+func EncodeAdminListReputationsResponse(reputations []ClientReputation, nextCursor string) (*fcrmessages.FCRMessage, error) {
+	return wire.Encode(AdminListReputationsResponseType, AdminListReputationsResponse{reputations, nextCursor})
+}
+
+// DecodeAdminListReputationsResponse is used to get the fields from FCRMessage of AdminListReputationsResponse
+func DecodeAdminListReputationsResponse(fcrMsg *fcrmessages.FCRMessage) ([]ClientReputation, string, error) {
+	msg := AdminListReputationsResponse{}
+	if err := wire.Decode(fcrMsg, AdminListReputationsResponseType, &msg); err != nil {
+		return nil, "", err
+	}
+	return msg.Reputations, msg.NextCursor, nil
+}
+
+// DecodeAdminGetReputationResponseExtension is used to get the optional history fields from
+// FCRMessage of fcrmessages.AdminGetReputationResponse. They are zero if the gateway does not
+// record history.
+func DecodeAdminGetReputationResponseExtension(fcrMsg *fcrmessages.FCRMessage) (*AdminGetReputationResponseExtension, error) {
+	msg := AdminGetReputationResponseExtension{}
+	if err := wire.Decode(fcrMsg, fcrmessages.AdminGetReputationResponseType, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
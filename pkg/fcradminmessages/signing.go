@@ -0,0 +1,78 @@
+package fcradminmessages
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+// digestDomain separates admin message digests from any other data signed with the same key.
+const digestDomain = "fcr-admin-message-v1"
+
+// signatureHeaderSize is the length of the key version which prefixes each signature.
+const signatureHeaderSize = 4
+
+// signedDigest is what is signed for an admin message. fcrcrypto.SignMessage only signs the
+// string fields of a struct, and FCRMessage has no string field other than its signature,
+// so signing an FCRMessage directly binds none of its content. Its digest is signed instead.
+type signedDigest struct {
+	Digest string
+}
+
+// MessageDigest returns the hex encoded SHA-256 digest of a message's type, protocol versions
+// and body. The body carries the request nonce and expiry, so they are covered too.
+func MessageDigest(msg *fcrmessages.FCRMessage) string {
+	h := sha256.New()
+	h.Write([]byte(digestDomain))
+	var n [8]byte
+	binary.BigEndian.PutUint32(n[:4], uint32(msg.MessageType))
+	h.Write(n[:4])
+	binary.BigEndian.PutUint32(n[:4], uint32(msg.ProtocolVersion))
+	h.Write(n[:4])
+	binary.BigEndian.PutUint32(n[:4], uint32(len(msg.ProtocolSupported)))
+	h.Write(n[:4])
+	for _, v := range msg.ProtocolSupported {
+		binary.BigEndian.PutUint32(n[:4], uint32(v))
+		h.Write(n[:4])
+	}
+	binary.BigEndian.PutUint64(n[:], uint64(len(msg.MessageBody)))
+	h.Write(n[:])
+	h.Write(msg.MessageBody)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Sign returns the signature of a message's digest with the private key, in the format
+// produced by fcrcrypto.SignMessage. The message's own signature is ignored.
+func Sign(key *fcrcrypto.KeyPair, keyVersion *fcrcrypto.KeyVersion, msg *fcrmessages.FCRMessage) (string, error) {
+	return fcrcrypto.SignMessage(key, keyVersion, &signedDigest{Digest: MessageDigest(msg)})
+}
+
+// SignMessage signs a message's digest with the private key and sets the message's signature.
+func SignMessage(key *fcrcrypto.KeyPair, keyVersion *fcrcrypto.KeyVersion, msg *fcrmessages.FCRMessage) error {
+	sig, err := Sign(key, keyVersion, msg)
+	if err != nil {
+		return err
+	}
+	msg.Signature = sig
+	return nil
+}
+
+// VerifyMessage returns true if the message's signature is a signature of its digest by the
+// public key. It returns an error for a malformed signature.
+func VerifyMessage(pubKey *fcrcrypto.KeyPair, msg *fcrmessages.FCRMessage) (bool, error) {
+	// fcrcrypto.VerifyMessage panics on a signature too short to hold a key version.
+	sig, err := hex.DecodeString(msg.Signature)
+	if err != nil {
+		return false, err
+	}
+	if len(sig) <= signatureHeaderSize {
+		return false, errors.New("Signature is too short")
+	}
+	return fcrcrypto.VerifyMessage(pubKey, msg.Signature, &signedDigest{Digest: MessageDigest(msg)})
+}
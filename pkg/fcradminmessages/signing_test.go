@@ -0,0 +1,98 @@
+package fcradminmessages
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"testing"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+func signedMessage(t *testing.T, key *fcrcrypto.KeyPair) *fcrmessages.FCRMessage {
+	t.Helper()
+	msg := &fcrmessages.FCRMessage{
+		MessageType:       AdminListOffersChallengeType,
+		ProtocolVersion:   1,
+		ProtocolSupported: []int32{1, 1},
+		MessageBody:       []byte(`{"cursor":"","limit":10,"nonce":"aa","expiry":100}`),
+	}
+	if err := SignMessage(key, fcrcrypto.DecodeKeyVersion(1), msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestVerifyMessage(t *testing.T) {
+	key, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     *fcrcrypto.KeyPair
+		tamper  func(msg *fcrmessages.FCRMessage)
+		want    bool
+		wantErr bool
+	}{
+		{"unchanged", key, func(*fcrmessages.FCRMessage) {}, true, false},
+		{"other key", other, func(*fcrmessages.FCRMessage) {}, false, false},
+		{"body changed", key, func(msg *fcrmessages.FCRMessage) {
+			msg.MessageBody = []byte(`{"cursor":"","limit":10,"nonce":"bb","expiry":100}`)
+		}, false, false},
+		{"type changed", key, func(msg *fcrmessages.FCRMessage) { msg.MessageType = AdminEvictOffersChallengeType }, false, false},
+		{"protocol version changed", key, func(msg *fcrmessages.FCRMessage) { msg.ProtocolVersion = 2 }, false, false},
+		{"supported versions changed", key, func(msg *fcrmessages.FCRMessage) { msg.ProtocolSupported = []int32{1} }, false, false},
+		{"signature too short", key, func(msg *fcrmessages.FCRMessage) { msg.Signature = "00000001" }, false, true},
+		{"signature not hex", key, func(msg *fcrmessages.FCRMessage) { msg.Signature = "zz" }, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := signedMessage(t, key)
+			tt.tamper(msg)
+			got, err := VerifyMessage(tt.key, msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyMessage() error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyMessage() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+// A signature copied from one message must not verify another.
+func TestSignatureDoesNotTransfer(t *testing.T) {
+	key, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := signedMessage(t, key)
+	forged := &fcrmessages.FCRMessage{
+		MessageType:       AdminPinOffersChallengeType,
+		ProtocolVersion:   msg.ProtocolVersion,
+		ProtocolSupported: msg.ProtocolSupported,
+		MessageBody:       []byte(`{"provider_id":"01"}`),
+		Signature:         msg.Signature,
+	}
+	ok, err := VerifyMessage(key, forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("signature of one message verified a forged message")
+	}
+}
+
+func TestMessageDigestIsUnambiguous(t *testing.T) {
+	a := &fcrmessages.FCRMessage{ProtocolSupported: []int32{1}, MessageBody: []byte{0, 0, 0, 2}}
+	b := &fcrmessages.FCRMessage{ProtocolSupported: []int32{1, 0}, MessageBody: []byte{2}}
+	if MessageDigest(a) == MessageDigest(b) {
+		t.Fatal("different messages have the same digest")
+	}
+}
@@ -0,0 +1,39 @@
+package fcrgatewayadmin
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client proxy and jump host dialers
+
+import (
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/dialer"
+)
+
+// SOCKS5Dialer connects to gateways through a SOCKS5 proxy.
+type SOCKS5Dialer = dialer.SOCKS5
+
+// HTTPConnectDialer connects to gateways through an HTTP proxy using the CONNECT method.
+type HTTPConnectDialer = dialer.HTTPConnect
+
+// SSHJumpConfig configures an SSH jump host.
+type SSHJumpConfig = dialer.SSHConfig
+
+// SSHJumpDialer connects to gateways through an SSH jump host.
+type SSHJumpDialer = dialer.SSHJump
+
+// NewSSHJumpDialer creates a dialer for an SSH jump host. The jump host's key must be in
+// the known hosts file.
+func NewSSHJumpDialer(conf SSHJumpConfig) (*SSHJumpDialer, error) {
+	return dialer.NewSSHJump(conf)
+}
+
+// ParseProxyURL creates a dialer from a proxy URL, which is one of:
+//
+//	socks5://[user:password@]host:port
+//	http://[user:password@]host:port or https://... for an HTTP CONNECT proxy
+//	ssh://user@host[:port]?key=/path/to/key[&known_hosts=/path/to/known_hosts]
+//
+// Set it for all gateways with SettingsBuilder.SetDialer, or for one gateway with
+// SettingsBuilder.SetGatewayDialer.
+func ParseProxyURL(proxyURL string) (Dialer, error) {
+	return dialer.Parse(proxyURL)
+}
@@ -0,0 +1,62 @@
+package fcrgatewayadmin
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client errors
+
+import (
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/adminerrors"
+)
+
+// Errors returned by the client match these with errors.Is. Use errors.As with *Error,
+// *KeyRejectedError, *UnexpectedMessageError, *KeyChangedError or *UnapprovedKeyError for
+// the details.
+var (
+	// ErrGatewayUnreachable is returned when a gateway can not be connected to, or the
+	// connection fails while exchanging messages.
+	ErrGatewayUnreachable = adminerrors.ErrGatewayUnreachable
+	// ErrSignatureInvalid is returned when a response is not signed by the gateway's key.
+	ErrSignatureInvalid = adminerrors.ErrSignatureInvalid
+	// ErrKeyRejected is returned when a gateway does not accept a private key.
+	ErrKeyRejected = adminerrors.ErrKeyRejected
+	// ErrUnexpectedMessage is returned when a gateway responds with a message of the wrong type.
+	ErrUnexpectedMessage = adminerrors.ErrUnexpectedMessage
+	// ErrProtocolMismatch is returned when a gateway does not speak this client's protocol version.
+	ErrProtocolMismatch = adminerrors.ErrProtocolMismatch
+	// ErrGatewayBlocked is returned when an operation targets a blocked gateway.
+	ErrGatewayBlocked = adminerrors.ErrGatewayBlocked
+	// ErrRegisterFailure is returned when the register can not be read or updated.
+	ErrRegisterFailure = adminerrors.ErrRegisterFailure
+	// ErrKeyChanged is returned when a gateway signs a response with a key which conflicts
+	// with its known keys, which could mean the gateway is being impersonated.
+	ErrKeyChanged = adminerrors.ErrKeyChanged
+)
+
+// Error is an error of one of the sentinel kinds, with the gateway it concerns and its cause.
+type Error = adminerrors.Error
+
+// KeyRejectedError is returned when a gateway does not accept a private key.
+type KeyRejectedError = adminerrors.KeyRejectedError
+
+// KeyRejectionReason is the reason code a gateway gives for rejecting a private key.
+type KeyRejectionReason = adminerrors.KeyRejectionReason
+
+// Key rejection reasons. ReasonUnspecified is reported by gateways which do not send reasons.
+const (
+	ReasonUnspecified        = adminerrors.ReasonUnspecified
+	ReasonAlreadyInitialized = adminerrors.ReasonAlreadyInitialized
+	ReasonBadKeyVersion      = adminerrors.ReasonBadKeyVersion
+	ReasonDecodeFailure      = adminerrors.ReasonDecodeFailure
+	ReasonUnauthorizedAdmin  = adminerrors.ReasonUnauthorizedAdmin
+)
+
+// UnexpectedMessageError is returned when a gateway responds with a message of the wrong type.
+type UnexpectedMessageError = adminerrors.UnexpectedMessageError
+
+// KeyChangedError is returned when a gateway signs a response with a key which conflicts
+// with its known keys.
+type KeyChangedError = adminerrors.KeyChangedError
+
+// UnapprovedKeyError is returned when a gateway signs a response with a key other than its
+// pinned key, until an operator approves the key change.
+type UnapprovedKeyError = adminerrors.UnapprovedKeyError
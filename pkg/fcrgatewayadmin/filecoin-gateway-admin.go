@@ -33,13 +33,19 @@ type FilecoinRetrievalGatewayAdminClient struct {
 	// TODO have a list of gateway objects of all the current gateways being interacted with
 }
 
-// NewFilecoinRetrievalGatewayAdminClient initialise the Filecoin Retreival Client library
-func NewFilecoinRetrievalGatewayAdminClient(conf Settings) *FilecoinRetrievalGatewayAdminClient {
+// NewFilecoinRetrievalGatewayAdminClient initialise the Filecoin Retreival Client library.
+// It returns an error if the client's TLS settings, metrics endpoint, inventory, key pins or
+// audit log can not be set up.
+func NewFilecoinRetrievalGatewayAdminClient(conf Settings) (*FilecoinRetrievalGatewayAdminClient, error) {
 	var c = FilecoinRetrievalGatewayAdminClient{}
-	log.Info("Filecoin Retrieval Gateway Admin Client started")
 	clientSettings := conf.(*settings.ClientGatewayAdminSettings)
-	c.gatewayManager = control.NewGatewayManager(*clientSettings)
-	return &c
+	gatewayManager, err := control.NewGatewayManager(*clientSettings)
+	if err != nil {
+		return nil, err
+	}
+	c.gatewayManager = gatewayManager
+	log.Info("Filecoin Retrieval Gateway Admin Client started")
+	return &c, nil
 
 }
 
@@ -63,25 +69,31 @@ func (c *FilecoinRetrievalGatewayAdminClient) InitializeGateway(gatewayInfo *reg
 }
 
 // ResetClientReputation requests a Gateway to initialise a client's reputation to the default value.
+//
+// Deprecated: the gateway is not known, so nothing is sent and false is always returned.
+// Use the client's SetClientReputation method.
 func ResetClientReputation(clientID *nodeid.NodeID) bool {
 	log.Info("Filecoin Retrieval Gateway Admin Client: InitialiseClientReputation(clientID: %s", clientID)
-	// TODO DHW
 	log.Info("InitialiseClientReputation(clientID: %s) failed to initialise reputation.", clientID)
 	return false
 }
 
 // SetClientReputation requests a Gateway to set a client's reputation to a specified value.
+//
+// Deprecated: the gateway is not known, so nothing is sent and false is always returned.
+// Use the client's SetClientReputation method.
 func SetClientReputation(clientID *nodeid.NodeID, rep int64) bool {
 	log.Info("Filecoin Retrieval Gateway Admin Client: SetClientReputation(clientID: %s, reputation: %d", clientID, rep)
-	// TODO DHW
 	log.Info("SetClientReputation(clientID: %s, reputation: %d) failed to set reputation.", clientID, rep)
 	return false
 }
 
 // GetCIDOffersList requests a Gateway's current list of CID Offers.
+//
+// Deprecated: the gateway is not known, so nothing is sent and an empty list is always
+// returned. Use the client's GetCIDOffersList method.
 func GetCIDOffersList() *list.List {
 	log.Info("Filecoin Retrieval Gateway Admin Client: GetCIDOffersList()")
-	// TODO
 	log.Info("GetCIDOffersList() failed to find any CID Offers.")
 	emptyList := list.New()
 	return emptyList
@@ -0,0 +1,110 @@
+package fcrgatewayadmin
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client managed gateway inventory
+
+import (
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/gatewaykeys"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/inventory"
+)
+
+// ManagedGateway is the inventory record of a gateway managed by this client.
+type ManagedGateway = inventory.Gateway
+
+// ErrGatewayNotManaged is returned when a gateway is not in the inventory.
+var ErrGatewayNotManaged = inventory.ErrNotFound
+
+// AddGateway adds an already initialised gateway to the inventory of managed gateways.
+func (c *FilecoinRetrievalGatewayAdminClient) AddGateway(gatewayInfo *register.GatewayRegister) error {
+	log.Info("Filecoin Retrieval Gateway Admin Client: AddGateway(%s)", gatewayInfo.NodeID)
+	return c.gatewayManager.AddGateway(gatewayInfo)
+}
+
+// RemoveGateway removes a gateway from the inventory of managed gateways.
+func (c *FilecoinRetrievalGatewayAdminClient) RemoveGateway(nodeID string) error {
+	log.Info("Filecoin Retrieval Gateway Admin Client: RemoveGateway(%s)", nodeID)
+	return c.gatewayManager.RemoveGateway(nodeID)
+}
+
+// GetGateway returns the inventory record of a managed gateway.
+func (c *FilecoinRetrievalGatewayAdminClient) GetGateway(nodeID string) (*ManagedGateway, error) {
+	return c.gatewayManager.GetGateway(nodeID)
+}
+
+// ListGateways returns the inventory records of all managed gateways.
+func (c *FilecoinRetrievalGatewayAdminClient) ListGateways() ([]ManagedGateway, error) {
+	return c.gatewayManager.ListGateways()
+}
+
+// GatewayKeys returns the signing keys known for a gateway, by key version. Responses from
+// the gateway are verified with the key for the key version in their signature. The signature
+// covers the digest of the whole response, so it binds the response's type and body.
+func (c *FilecoinRetrievalGatewayAdminClient) GatewayKeys(nodeID string) map[uint32]string {
+	return c.gatewayManager.GatewayKeys(nodeID)
+}
+
+// KeyPin is the signing key pinned for a gateway, with any pending key change.
+type KeyPin = gatewaykeys.Pin
+
+// ListKeyPins returns the signing keys pinned for each gateway. A gateway's key is pinned the
+// first time it is seen, and responses signed with any other key are rejected until the key
+// change is approved.
+func (c *FilecoinRetrievalGatewayAdminClient) ListKeyPins() ([]KeyPin, error) {
+	return c.gatewayManager.ListKeyPins()
+}
+
+// ApproveGatewayKey approves the pending key change of a gateway, pinning the new key. The
+// gateway's other known keys are retired, so responses signed with them are rejected, unless
+// keepOld is true.
+func (c *FilecoinRetrievalGatewayAdminClient) ApproveGatewayKey(nodeID string, keepOld bool) error {
+	log.Info("Filecoin Retrieval Gateway Admin Client: ApproveGatewayKey(%s, keepOld: %t)", nodeID, keepOld)
+	return c.gatewayManager.ApproveGatewayKey(nodeID, keepOld)
+}
+
+// RevokeGatewayKey revokes the pinned key of a gateway. The next key seen for the gateway is
+// pinned as on first use.
+func (c *FilecoinRetrievalGatewayAdminClient) RevokeGatewayKey(nodeID string) error {
+	log.Info("Filecoin Retrieval Gateway Admin Client: RevokeGatewayKey(%s)", nodeID)
+	return c.gatewayManager.RevokeGatewayKey(nodeID)
+}
+
+// BlockGateway stops any further admin operations being sent to a gateway.
+func (c *FilecoinRetrievalGatewayAdminClient) BlockGateway(nodeID string) error {
+	log.Info("Filecoin Retrieval Gateway Admin Client: BlockGateway(%s)", nodeID)
+	return c.gatewayManager.BlockGateway(nodeID)
+}
+
+// UnblockGateway allows admin operations to be sent to a previously blocked gateway.
+func (c *FilecoinRetrievalGatewayAdminClient) UnblockGateway(nodeID string) error {
+	log.Info("Filecoin Retrieval Gateway Admin Client: UnblockGateway(%s)", nodeID)
+	return c.gatewayManager.UnblockGateway(nodeID)
+}
+
+// SetGatewayTags adds or replaces key/value tags on a managed gateway.
+func (c *FilecoinRetrievalGatewayAdminClient) SetGatewayTags(nodeID string, tags map[string]string) error {
+	log.Info("Filecoin Retrieval Gateway Admin Client: SetGatewayTags(%s)", nodeID)
+	return c.gatewayManager.SetGatewayTags(nodeID, tags)
+}
+
+// RemoveGatewayTags removes tags from a managed gateway.
+func (c *FilecoinRetrievalGatewayAdminClient) RemoveGatewayTags(nodeID string, keys []string) error {
+	log.Info("Filecoin Retrieval Gateway Admin Client: RemoveGatewayTags(%s)", nodeID)
+	return c.gatewayManager.RemoveGatewayTags(nodeID, keys)
+}
+
+// SelectGateways returns the managed gateways whose tags match a label selector such
+// as "region=eu,tier in (edge,core),!canary".
+func (c *FilecoinRetrievalGatewayAdminClient) SelectGateways(selector string) ([]ManagedGateway, error) {
+	return c.gatewayManager.SelectGateways(selector)
+}
+
+// ForEachGateway runs an operation against every managed gateway matching a label selector,
+// returning the result for each gateway keyed by node ID.
+func (c *FilecoinRetrievalGatewayAdminClient) ForEachGateway(selector string, op func(gw ManagedGateway) error) (map[string]error, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: ForEachGateway(%s)", selector)
+	return c.gatewayManager.ForEachGateway(selector, op)
+}
@@ -0,0 +1,38 @@
+package fcrgatewayadmin
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client declarative fleet configuration
+
+import (
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/fleetplan"
+)
+
+// FleetConfig is the desired state of a fleet of gateways.
+type FleetConfig = fleetplan.Config
+
+// FleetPlan is the set of actions needed to bring a fleet to its desired state.
+type FleetPlan = fleetplan.Plan
+
+// FleetActionResult is the outcome of applying one action of a fleet plan.
+type FleetActionResult = fleetplan.Result
+
+// LoadFleetConfig reads a YAML desired state file.
+func LoadFleetConfig(path string) (*FleetConfig, error) {
+	return fleetplan.Load(path)
+}
+
+// PlanFleet compares the desired state of the fleet with its live state in the register,
+// the inventory and each gateway, and returns the actions needed to reconcile them.
+func (c *FilecoinRetrievalGatewayAdminClient) PlanFleet(desired *FleetConfig) (*FleetPlan, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: PlanFleet()")
+	return c.gatewayManager.PlanFleet(desired)
+}
+
+// ApplyFleet carries out the actions of a fleet plan. If dryRun is true no changes are made.
+func (c *FilecoinRetrievalGatewayAdminClient) ApplyFleet(plan *FleetPlan, dryRun bool) []FleetActionResult {
+	log.Info("Filecoin Retrieval Gateway Admin Client: ApplyFleet(dryRun: %t)", dryRun)
+	return c.gatewayManager.ApplyFleet(plan, dryRun)
+}
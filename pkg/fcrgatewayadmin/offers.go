@@ -0,0 +1,182 @@
+package fcrgatewayadmin
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client CID offer cache management
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/adminerrors"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/consistency"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/control"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/offerexport"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/offerverify"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+// OfferAck is a gateway's signed acknowledgement of an offer operation.
+type OfferAck = control.OfferAck
+
+// EvictOffers removes offers from a gateway's cache. Offers are identified by the provider
+// and their merkle roots; if no merkle roots are given all of the provider's offers are removed.
+func (c *FilecoinRetrievalGatewayAdminClient) EvictOffers(gatewayInfo *register.GatewayRegister, providerID *nodeid.NodeID, merkleRoots ...string) (*OfferAck, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: EvictOffers(%s, provider: %s)", gatewayInfo.NodeID, providerID.ToString())
+	return c.gatewayManager.EvictOffers(gatewayInfo, providerID, merkleRoots)
+}
+
+// PinOffers stops a gateway expiring offers from its cache. If no merkle roots are given all of
+// the provider's offers are pinned.
+func (c *FilecoinRetrievalGatewayAdminClient) PinOffers(gatewayInfo *register.GatewayRegister, providerID *nodeid.NodeID, merkleRoots ...string) (*OfferAck, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: PinOffers(%s, provider: %s)", gatewayInfo.NodeID, providerID.ToString())
+	return c.gatewayManager.PinOffers(gatewayInfo, providerID, merkleRoots, true)
+}
+
+// UnpinOffers allows a gateway to expire previously pinned offers.
+func (c *FilecoinRetrievalGatewayAdminClient) UnpinOffers(gatewayInfo *register.GatewayRegister, providerID *nodeid.NodeID, merkleRoots ...string) (*OfferAck, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: UnpinOffers(%s, provider: %s)", gatewayInfo.NodeID, providerID.ToString())
+	return c.gatewayManager.PinOffers(gatewayInfo, providerID, merkleRoots, false)
+}
+
+// RefreshOffers makes a gateway fetch a provider's current offers from the provider.
+func (c *FilecoinRetrievalGatewayAdminClient) RefreshOffers(gatewayInfo *register.GatewayRegister, providerID *nodeid.NodeID) (*OfferAck, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: RefreshOffers(%s, provider: %s)", gatewayInfo.NodeID, providerID.ToString())
+	return c.gatewayManager.RefreshOffers(gatewayInfo, providerID)
+}
+
+// Offer is a CID group offer held by a gateway, with the provider's signature.
+type Offer = fcradminmessages.Offer
+
+// OfferVerifier checks offers against the signing keys of the providers in the register.
+type OfferVerifier = offerverify.Verifier
+
+// OfferVerification is the result of verifying one offer.
+type OfferVerification = offerverify.Result
+
+// OfferConsistencyReport lists the differences between the offers held by several gateways.
+type OfferConsistencyReport = consistency.Report
+
+// GetCIDOffersList reads every offer in a gateway's cache. Use ExportOffers for caches too
+// large to hold in memory.
+func (c *FilecoinRetrievalGatewayAdminClient) GetCIDOffersList(gatewayInfo *register.GatewayRegister) ([]Offer, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: GetCIDOffersList(%s)", gatewayInfo.NodeID)
+	var all []Offer
+	err := c.gatewayManager.StreamOffers("get-cid-offers-list", gatewayInfo, "", func(offers []Offer, next string) error {
+		all = append(all, offers...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// ExportOffers streams every offer in a gateway's cache to w, one page at a time, as jsonl, csv
+// or bin. The offers include the provider signatures so they can be verified offline.
+//
+// resumeToken is empty for a new export. If an export fails part way, it returns a token
+// which continues it from the last complete page; the output of the resumed export should
+// be appended to the earlier output. The count is the number of offers exported in total.
+func (c *FilecoinRetrievalGatewayAdminClient) ExportOffers(gatewayInfo *register.GatewayRegister, w io.Writer, format string, resumeToken string) (count int, token string, err error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: ExportOffers(%s, %s)", gatewayInfo.NodeID, format)
+	f, err := offerexport.ParseFormat(format)
+	if err != nil {
+		return 0, resumeToken, err
+	}
+	progress := offerexport.ResumeToken{Gateway: gatewayInfo.NodeID}
+	if resumeToken != "" {
+		t, err := offerexport.DecodeResumeToken(resumeToken)
+		if err != nil {
+			return 0, resumeToken, err
+		}
+		if !strings.EqualFold(t.Gateway, gatewayInfo.NodeID) {
+			return 0, resumeToken, fmt.Errorf("Resume token is for gateway %s, not %s", t.Gateway, gatewayInfo.NodeID)
+		}
+		progress = *t
+	}
+	writer, err := offerexport.NewWriter(w, f, resumeToken != "")
+	if err != nil {
+		return progress.Count, resumeToken, err
+	}
+
+	err = c.gatewayManager.StreamOffers("export-offers", gatewayInfo, progress.Cursor, func(offers []Offer, next string) error {
+		for i := range offers {
+			if err := writer.Write(&offers[i]); err != nil {
+				return err
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		progress.Cursor = next
+		progress.Count += len(offers)
+		return nil
+	})
+	if err != nil {
+		return progress.Count, progress.Encode(), err
+	}
+	return progress.Count, "", nil
+}
+
+// NewOfferVerifier creates an offer verifier from the provider keys currently in the register.
+func (c *FilecoinRetrievalGatewayAdminClient) NewOfferVerifier() (*OfferVerifier, error) {
+	return c.gatewayManager.NewOfferVerifier()
+}
+
+// NewRegisterOfferVerifier creates an offer verifier from the provider keys in the register
+// at registerURL, for verifying exported offers without an admin client.
+func NewRegisterOfferVerifier(registerURL string) (*OfferVerifier, error) {
+	providers, err := register.GetRegisteredProviders(registerURL)
+	if err != nil {
+		return nil, adminerrors.New(adminerrors.ErrRegisterFailure, "", fmt.Errorf("Unable to read providers from register: %s", err))
+	}
+	return offerverify.New(providers), nil
+}
+
+// VerifyOffers reads every offer in a gateway's cache and checks that it is signed by a
+// registered provider and has not expired, passing each result to fn. It stops if fn
+// returns an error.
+func (c *FilecoinRetrievalGatewayAdminClient) VerifyOffers(gatewayInfo *register.GatewayRegister, fn func(result OfferVerification) error) error {
+	log.Info("Filecoin Retrieval Gateway Admin Client: VerifyOffers(%s)", gatewayInfo.NodeID)
+	return c.gatewayManager.VerifyOffers(gatewayInfo, fn)
+}
+
+// VerifyOfferExport verifies the offers in an export written by ExportOffers, passing each
+// result to fn. It stops if fn returns an error.
+func VerifyOfferExport(r io.Reader, format string, verifier *OfferVerifier, fn func(result OfferVerification) error) error {
+	f, err := offerexport.ParseFormat(format)
+	if err != nil {
+		return err
+	}
+	reader, err := offerexport.NewReader(r, f)
+	if err != nil {
+		return err
+	}
+	for {
+		offer, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = fn(verifier.Verify(offer)); err != nil {
+			return err
+		}
+	}
+}
+
+// CheckOfferConsistency compares the offers held by every managed gateway matching a label
+// selector, reporting offers missing from some gateways, differing prices for the same CID
+// and provider, differing expiries and expired offers. With a sample rate below 1 only that
+// fraction of offers is compared; the same offers are sampled on every gateway.
+func (c *FilecoinRetrievalGatewayAdminClient) CheckOfferConsistency(selector string, sampleRate float64) (*OfferConsistencyReport, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: CheckOfferConsistency(%s, %g)", selector, sampleRate)
+	return c.gatewayManager.CheckOfferConsistency(selector, sampleRate)
+}
@@ -0,0 +1,100 @@
+package fcrgatewayadmin
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client client reputation management
+
+import (
+	"os"
+
+	log "github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/ConsenSys/fc-retrieval-register/pkg/register"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/reputationfile"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/pkg/fcradminmessages"
+)
+
+// ClientReputation is the reputation a gateway holds for a client.
+type ClientReputation = fcradminmessages.ClientReputation
+
+// ReputationFilter selects the client reputations returned by ListClientReputations.
+type ReputationFilter = fcradminmessages.ReputationFilter
+
+// ReputationPage is a page of client reputations. Pass NextCursor as the filter Cursor to
+// read the next page; it is empty on the last page.
+type ReputationPage = fcradminmessages.AdminListReputationsResponse
+
+// ReputationRow is one row of a reputation import, with the reason it could not be
+// imported if Err is set.
+type ReputationRow = reputationfile.Row
+
+// GetClientReputation reads a client's reputation from a gateway. It returns nil if the gateway
+// holds no reputation for the client. The signature of the whole response, body included, is
+// verified against the gateway's signing key.
+func (c *FilecoinRetrievalGatewayAdminClient) GetClientReputation(gatewayInfo *register.GatewayRegister, clientID *nodeid.NodeID) (*ClientReputation, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: GetClientReputation(%s, %s)", gatewayInfo.NodeID, clientID.ToString())
+	rep, exists, err := c.gatewayManager.GetClientReputation(gatewayInfo, clientID)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// SetClientReputation asks a gateway to set a client's reputation, and checks the gateway's
+// signed response confirms the new value.
+func (c *FilecoinRetrievalGatewayAdminClient) SetClientReputation(gatewayInfo *register.GatewayRegister, clientID *nodeid.NodeID, reputation int64) error {
+	log.Info("Filecoin Retrieval Gateway Admin Client: SetClientReputation(%s, %s, %d)", gatewayInfo.NodeID, clientID.ToString(), reputation)
+	return c.gatewayManager.SetClientReputation(gatewayInfo, clientID, reputation)
+}
+
+// ListClientReputations reads a page of the client reputations held by a gateway. The
+// signature of the whole response, body included, is verified against the gateway's signing key.
+func (c *FilecoinRetrievalGatewayAdminClient) ListClientReputations(gatewayInfo *register.GatewayRegister, filter *ReputationFilter) (*ReputationPage, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: ListClientReputations(%s)", gatewayInfo.NodeID)
+	return c.gatewayManager.ListClientReputations(gatewayInfo, filter)
+}
+
+// ExportClientReputations writes every client reputation held by a gateway to a file.
+// The format, csv or json, is taken from the file extension.
+func (c *FilecoinRetrievalGatewayAdminClient) ExportClientReputations(gatewayInfo *register.GatewayRegister, path string) (int, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: ExportClientReputations(%s, %s)", gatewayInfo.NodeID, path)
+	format, err := reputationfile.FormatFromPath(path)
+	if err != nil {
+		return 0, err
+	}
+	records, err := c.gatewayManager.ExportClientReputations(gatewayInfo)
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	if err = reputationfile.Write(f, format, gatewayInfo.NodeID, records); err != nil {
+		f.Close()
+		return 0, err
+	}
+	return len(records), f.Close()
+}
+
+// ImportClientReputations sets client reputations on a gateway from a csv or json file,
+// such as one written by ExportClientReputations. Every row is returned; those which were
+// invalid or could not be set have Err set. If dryRun is true the file is only validated.
+func (c *FilecoinRetrievalGatewayAdminClient) ImportClientReputations(gatewayInfo *register.GatewayRegister, path string, dryRun bool) ([]ReputationRow, error) {
+	log.Info("Filecoin Retrieval Gateway Admin Client: ImportClientReputations(%s, %s, dryRun: %t)", gatewayInfo.NodeID, path, dryRun)
+	format, err := reputationfile.FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rows, err := reputationfile.Read(f, format)
+	if err != nil {
+		return nil, err
+	}
+	return c.gatewayManager.ImportClientReputations(gatewayInfo, rows, dryRun), nil
+}
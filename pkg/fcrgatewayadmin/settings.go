@@ -6,6 +6,8 @@ package fcrgatewayadmin
 
 import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
 )
@@ -15,10 +17,10 @@ type SettingsBuilder interface {
 	// SetLogging sets the log level and target.
 	SetLogging(logLevel string, logTarget string, logServiceName string)
 
-	// SetEstablishmentTTL sets the time to live for the establishment message between client and gateway.
+	// SetEstablishmentTTL sets the time to live, in seconds, of admin requests.
 	SetEstablishmentTTL(ttl int64)
 
-	// SetBlockchainPrivateKey sets the blockchain private key.
+	// SetBlockchainPrivateKey sets the blockchain private key. It is optional: admin operations do not use it.
 	SetBlockchainPrivateKey(bcPkey *fcrcrypto.KeyPair)
 
 	// SetGatewayAdminPrivateKey sets the retrieval private key.
@@ -27,6 +29,64 @@ type SettingsBuilder interface {
 	// SetRegisterURL sets the URL of the register service.
 	SetRegisterURL(regURL string)
 
+	// SetAuditLog sets the audit log file and the operator identity recorded in it.
+	SetAuditLog(path string, operator string)
+
+	// SetSensitiveLogFields sets extra message fields which are masked in log output.
+	SetSensitiveLogFields(fields []string)
+
+	// SetUnsafeUnredactedLogging turns off masking of key material in log output. Local debugging only.
+	SetUnsafeUnredactedLogging(unsafe bool)
+
+	// SetMetricsRegisterer sets the Prometheus registry the library's metrics are registered with.
+	SetMetricsRegisterer(reg prometheus.Registerer)
+
+	// SetMetricsListenAddress sets the address of an optional HTTP endpoint serving /metrics.
+	SetMetricsListenAddress(addr string)
+
+	// SetTracerProvider sets the OpenTelemetry tracer provider used to trace admin operations.
+	SetTracerProvider(tp trace.TracerProvider)
+
+	// SetResolver sets the resolver used to look up the host names of gateways dialled directly, and of proxies and jump hosts.
+	SetResolver(r Resolver)
+
+	// SetTLS turns on TLS for the gateway admin channels, trusting the CAs in caFile, or the system CAs if empty.
+	SetTLS(caFile string)
+
+	// SetTLSClientCertificate sets the certificate presented to gateways for mutual TLS.
+	SetTLSClientCertificate(certFile string, keyFile string)
+
+	// SetTLSPinnedKeys sets the hex SHA-256 hashes of the gateway certificate public keys to accept.
+	SetTLSPinnedKeys(pins []string)
+
+	// SetEncryptGatewayKeys sets whether gateway private keys are encrypted end to end during initialisation. TLS must be enabled.
+	SetEncryptGatewayKeys(encrypt bool)
+
+	// SetAllowLegacyResponses sets whether responses from gateways which do not echo the request nonce are accepted.
+	// The default is false: responses which do not echo the nonce can be replayed.
+	SetAllowLegacyResponses(allow bool)
+
+	// SetInventoryPath sets the file used to persist the inventory of managed gateways. If not set, the inventory is held in memory.
+	SetInventoryPath(path string)
+
+	// SetTransport sets the transport admin messages are sent over. The default is the gateway admin TCP protocol. If TLS is enabled the transport must support TLS settings.
+	SetTransport(t Transport)
+
+	// SetDialer sets the dialer used to connect to gateways which have no dialer of their own.
+	SetDialer(d Dialer)
+
+	// SetGatewayDialer sets the dialer used to connect to one gateway.
+	SetGatewayDialer(nodeID string, d Dialer)
+
+	// SetConnectionPool sets the idle connection limit, idle timeout and TCP keepalive of the admin connection pool. An idle timeout shorter than 10ms is raised to 10ms.
+	SetConnectionPool(conf PoolConfig)
+
+	// SetKeyPinPath sets the file used to persist the signing keys pinned for each gateway. If not set, pins are held in memory.
+	SetKeyPinPath(path string)
+
+	// SetSigner sets the signer used to sign admin messages, for example a remote signer holding the admin private key. The default signs in process with the admin private key.
+	SetSigner(s Signer)
+
 	// Build creates a settings object and initialises the logging system.
 	Build() *Settings
 }
@@ -41,8 +101,56 @@ type Settings interface {
 	GatewayAdminPrivateKeyVer() *fcrcrypto.KeyVersion
 
 	RegisterURL() string
+
+	AuditLogPath() string
+	AuditOperator() string
+
+	SensitiveLogFields() []string
+	UnsafeUnredactedLogging() bool
+
+	MetricsRegisterer() prometheus.Registerer
+	MetricsListenAddress() string
+
+	TracerProvider() trace.TracerProvider
+
+	Resolver() Resolver
+
+	TLSEnabled() bool
+	TLSCAFile() string
+	TLSClientCertFile() string
+	TLSClientKeyFile() string
+	TLSPinnedKeys() []string
+
+	EncryptGatewayKeys() bool
+
+	AllowLegacyResponses() bool
+
+	InventoryPath() string
+
+	Transport() Transport
+
+	Dialer() Dialer
+	GatewayDialers() map[string]Dialer
+	DialerFor(nodeID string) Dialer
+
+	ConnectionPool() PoolConfig
+
+	KeyPinPath() string
+
+	Signer() Signer
 }
 
+// Resolver looks up the IP addresses of a host name. *net.Resolver implements this interface.
+type Resolver = settings.Resolver
+
+// Dialer opens connections to gateways, for example through a proxy or bastion host.
+// *net.Dialer implements this interface.
+type Dialer = settings.Dialer
+
+// PoolConfig configures the pool of admin connections to gateways. Zero fields take
+// the default values.
+type PoolConfig = settings.PoolConfig
+
 // CreateSettings loads up default settings
 func CreateSettings() SettingsBuilder {
 	f := newBuilderImpl()
@@ -63,12 +171,12 @@ func (f settingsBuilderImpl) SetLogging(logLevel string, logTarget string, logSe
 	f.impl.SetLogging(logLevel, logTarget, logServiceName)
 }
 
-// SetEstablishmentTTL sets the time to live for the establishment message between client and gateway.
+// SetEstablishmentTTL sets the time to live, in seconds, of admin requests.
 func (f settingsBuilderImpl) SetEstablishmentTTL(ttl int64) {
 	f.impl.SetEstablishmentTTL(ttl)
 }
 
-// SetBlockchainPrivateKey sets the blockchain private key.
+// SetBlockchainPrivateKey sets the blockchain private key. It is optional: admin operations do not use it.
 func (f settingsBuilderImpl) SetBlockchainPrivateKey(bcPkey *fcrcrypto.KeyPair) {
 	f.impl.SetBlockchainPrivateKey(bcPkey)
 }
@@ -83,6 +191,102 @@ func (f settingsBuilderImpl) SetRegisterURL(regURL string) {
 	f.impl.SetRegisterURL(regURL)
 }
 
+// SetAuditLog sets the audit log file and the operator identity recorded in it.
+func (f settingsBuilderImpl) SetAuditLog(path string, operator string) {
+	f.impl.SetAuditLog(path, operator)
+}
+
+// SetSensitiveLogFields sets extra message fields which are masked in log output.
+func (f settingsBuilderImpl) SetSensitiveLogFields(fields []string) {
+	f.impl.SetSensitiveLogFields(fields)
+}
+
+// SetUnsafeUnredactedLogging turns off masking of key material in log output. Local debugging only.
+func (f settingsBuilderImpl) SetUnsafeUnredactedLogging(unsafe bool) {
+	f.impl.SetUnsafeUnredactedLogging(unsafe)
+}
+
+// SetMetricsRegisterer sets the Prometheus registry the library's metrics are registered with.
+func (f settingsBuilderImpl) SetMetricsRegisterer(reg prometheus.Registerer) {
+	f.impl.SetMetricsRegisterer(reg)
+}
+
+// SetMetricsListenAddress sets the address of an optional HTTP endpoint serving /metrics.
+func (f settingsBuilderImpl) SetMetricsListenAddress(addr string) {
+	f.impl.SetMetricsListenAddress(addr)
+}
+
+// SetTracerProvider sets the OpenTelemetry tracer provider used to trace admin operations.
+func (f settingsBuilderImpl) SetTracerProvider(tp trace.TracerProvider) {
+	f.impl.SetTracerProvider(tp)
+}
+
+// SetResolver sets the resolver used to look up the host names of gateways dialled directly, and of proxies and jump hosts.
+func (f settingsBuilderImpl) SetResolver(r Resolver) {
+	f.impl.SetResolver(r)
+}
+
+// SetTLS turns on TLS for the gateway admin channels, trusting the CAs in caFile, or the system CAs if empty.
+func (f settingsBuilderImpl) SetTLS(caFile string) {
+	f.impl.SetTLS(caFile)
+}
+
+// SetTLSClientCertificate sets the certificate presented to gateways for mutual TLS.
+func (f settingsBuilderImpl) SetTLSClientCertificate(certFile string, keyFile string) {
+	f.impl.SetTLSClientCertificate(certFile, keyFile)
+}
+
+// SetTLSPinnedKeys sets the hex SHA-256 hashes of the gateway certificate public keys to accept.
+func (f settingsBuilderImpl) SetTLSPinnedKeys(pins []string) {
+	f.impl.SetTLSPinnedKeys(pins)
+}
+
+// SetEncryptGatewayKeys sets whether gateway private keys are encrypted end to end during initialisation. TLS must be enabled.
+func (f settingsBuilderImpl) SetEncryptGatewayKeys(encrypt bool) {
+	f.impl.SetEncryptGatewayKeys(encrypt)
+}
+
+// SetAllowLegacyResponses sets whether responses from gateways which do not echo the request nonce are accepted.
+// The default is false: responses which do not echo the nonce can be replayed.
+func (f settingsBuilderImpl) SetAllowLegacyResponses(allow bool) {
+	f.impl.SetAllowLegacyResponses(allow)
+}
+
+// SetInventoryPath sets the file used to persist the inventory of managed gateways. If not set, the inventory is held in memory.
+func (f settingsBuilderImpl) SetInventoryPath(path string) {
+	f.impl.SetInventoryPath(path)
+}
+
+// SetTransport sets the transport admin messages are sent over. The default is the gateway admin TCP protocol. If TLS is enabled the transport must support TLS settings.
+func (f settingsBuilderImpl) SetTransport(t Transport) {
+	f.impl.SetTransport(t)
+}
+
+// SetDialer sets the dialer used to connect to gateways which have no dialer of their own.
+func (f settingsBuilderImpl) SetDialer(d Dialer) {
+	f.impl.SetDialer(d)
+}
+
+// SetGatewayDialer sets the dialer used to connect to one gateway.
+func (f settingsBuilderImpl) SetGatewayDialer(nodeID string, d Dialer) {
+	f.impl.SetGatewayDialer(nodeID, d)
+}
+
+// SetConnectionPool sets the idle connection limit, idle timeout and TCP keepalive of the admin connection pool. An idle timeout shorter than 10ms is raised to 10ms.
+func (f settingsBuilderImpl) SetConnectionPool(conf PoolConfig) {
+	f.impl.SetConnectionPool(conf)
+}
+
+// SetKeyPinPath sets the file used to persist the signing keys pinned for each gateway. If not set, pins are held in memory.
+func (f settingsBuilderImpl) SetKeyPinPath(path string) {
+	f.impl.SetKeyPinPath(path)
+}
+
+// SetSigner sets the signer used to sign admin messages, for example a remote signer holding the admin private key. The default signs in process with the admin private key.
+func (f settingsBuilderImpl) SetSigner(s Signer) {
+	f.impl.SetSigner(s)
+}
+
 // Build generates the settings.
 func (f settingsBuilderImpl) Build() *Settings {
 	clientSettings := f.impl.Build()
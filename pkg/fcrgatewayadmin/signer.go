@@ -0,0 +1,56 @@
+package fcrgatewayadmin
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client signers
+
+import (
+	"net"
+	"os/user"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/audit"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/signer"
+)
+
+// Signer signs admin messages with the admin private key.
+// Set it with SettingsBuilder.SetSigner.
+type Signer = settings.Signer
+
+// NewLocalSigner creates a signer which signs in process with the given private key.
+func NewLocalSigner(key *fcrcrypto.KeyPair, version *fcrcrypto.KeyVersion) Signer {
+	return signer.NewLocal(key, version)
+}
+
+// NewRemoteSigner creates a signer which sends messages to a signing daemon holding the
+// admin private key. The address is a Unix socket path, optionally prefixed with unix://,
+// or tcp://host:port for a daemon listening on a loopback IP address.
+func NewRemoteSigner(address string) (Signer, error) {
+	return signer.NewRemote(address)
+}
+
+// ServeSigner runs a signing daemon, signing the admin challenges sent by remote signers
+// connecting to the listener with the given signer. Unix socket clients must run as the
+// daemon's user, where the platform can tell, and TCP clients must connect from a loopback
+// address. If auditLogPath is not empty each signing request is recorded in the audit log
+// there against operator, or the current user if operator is empty, and a signature is only
+// returned once it has been recorded. It returns when the listener is closed.
+func ServeSigner(l net.Listener, s Signer, auditLogPath string, operator string) error {
+	var auditLog *audit.Log
+	if auditLogPath != "" {
+		if operator == "" {
+			current, err := user.Current()
+			if err != nil {
+				return err
+			}
+			operator = current.Username
+		}
+		var err error
+		if auditLog, err = audit.Open(auditLogPath, operator); err != nil {
+			return err
+		}
+	}
+	return signer.Serve(l, s, auditLog)
+}
@@ -0,0 +1,29 @@
+package fcrgatewayadmin
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client tracing exporters
+
+import (
+	"io"
+
+	"go.opentelemetry.io/otel/exporters/stdout"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// NewStdoutTracerProvider creates a tracer provider which writes each span to w as JSON.
+// Pass it to SettingsBuilder.SetTracerProvider.
+func NewStdoutTracerProvider(w io.Writer) (*sdktrace.TracerProvider, error) {
+	exporter, err := stdout.NewExporter(stdout.WithWriter(w), stdout.WithPrettyPrint(), stdout.WithoutMetricExport())
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), nil
+}
+
+// NewInMemoryTracerProvider creates a tracer provider which keeps spans in memory, for use in tests.
+func NewInMemoryTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
@@ -0,0 +1,55 @@
+package fcrgatewayadmin
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+// Filecoin Retrieval Gateway Admin Client transports
+
+import (
+	"net/http"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/settings"
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/transport"
+)
+
+// Transport carries signed admin messages to gateways and returns their responses.
+// Set it with SettingsBuilder.SetTransport.
+type Transport = settings.Transport
+
+// Endpoint is the admin interface of a gateway, as passed to a Transport.
+type Endpoint = settings.Endpoint
+
+// PoolStats are the statistics of the admin connection pool.
+type PoolStats = transport.PoolStats
+
+// MemoryTransport delivers admin messages to handlers in the same process. It can not be
+// used with TLS enabled.
+type MemoryTransport = transport.Memory
+
+// MemoryHandler handles an admin message sent to an in-memory gateway.
+type MemoryHandler = transport.Handler
+
+// NewTCPTransport creates a transport using the gateway admin TCP protocol with the default
+// connection pool configuration. If TLS is enabled in the settings the connections use it.
+// A TCP transport is the default when no transport is set.
+func NewTCPTransport() Transport {
+	return transport.NewTCP(nil, settings.PoolConfig{})
+}
+
+// NewHTTPTransport creates a transport posting admin messages to the gateway's admin HTTP
+// endpoint using client, which may be nil. If useTLS is true requests use HTTPS. If TLS is
+// enabled in the settings requests use HTTPS with those settings in place of the client's
+// TLS configuration, which requires the client's transport to be an *http.Transport.
+func NewHTTPTransport(client *http.Client, useTLS bool) Transport {
+	return transport.NewHTTP(client, useTLS)
+}
+
+// NewMemoryTransport creates an in-memory transport with no gateways.
+func NewMemoryTransport() *MemoryTransport {
+	return transport.NewMemory()
+}
+
+// ConnectionPoolStats returns the statistics of the admin connection pool. It returns false
+// if the transport does not pool connections.
+func (c *FilecoinRetrievalGatewayAdminClient) ConnectionPoolStats() (PoolStats, bool) {
+	return c.gatewayManager.ConnectionPoolStats()
+}
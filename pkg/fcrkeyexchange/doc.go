@@ -0,0 +1,7 @@
+// Package fcrkeyexchange encrypts gateway private keys end to end from the admin client
+// to the gateway. The gateway offers an X25519 exchange key; the admin client derives a
+// shared key from an ephemeral X25519 key pair using HKDF-SHA256 and seals the private
+// key with ChaCha20-Poly1305, binding the gateway node ID and key version as associated data.
+package fcrkeyexchange
+
+// Copyright (C) 2020 ConsenSys Software Inc
@@ -0,0 +1,161 @@
+package fcrkeyexchange
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const hkdfInfo = "fcr-admin-key-envelope-v1"
+
+// PublicKey is an X25519 exchange public key.
+type PublicKey [curve25519.PointSize]byte
+
+// PrivateKey is an X25519 exchange private key.
+type PrivateKey struct {
+	private [curve25519.ScalarSize]byte
+	public  PublicKey
+}
+
+// Envelope is a private key sealed to a gateway's exchange key.
+type Envelope struct {
+	EphemeralPublicKey string `json:"ephemeral_public_key"`
+	Nonce              string `json:"nonce"`
+	Ciphertext         string `json:"ciphertext"`
+}
+
+// GenerateKey creates a new exchange key pair. Gateways should create a fresh key
+// pair for each initialisation and discard it afterwards.
+func GenerateKey() (*PrivateKey, error) {
+	k := PrivateKey{}
+	if _, err := io.ReadFull(rand.Reader, k.private[:]); err != nil {
+		return nil, err
+	}
+	pub, err := curve25519.X25519(k.private[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	copy(k.public[:], pub)
+	return &k, nil
+}
+
+// Public returns the public half of the key pair.
+func (k *PrivateKey) Public() *PublicKey {
+	pub := k.public
+	return &pub
+}
+
+// Encode returns the public key as a hex string.
+func (p *PublicKey) Encode() string {
+	return hex.EncodeToString(p[:])
+}
+
+// DecodePublicKey decodes a hex encoded exchange public key.
+func DecodePublicKey(encoded string) (*PublicKey, error) {
+	raw, err := hex.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != curve25519.PointSize {
+		return nil, fmt.Errorf("Exchange public key must be %d bytes, not %d", curve25519.PointSize, len(raw))
+	}
+	p := PublicKey{}
+	copy(p[:], raw)
+	return &p, nil
+}
+
+// Seal encrypts plaintext to the gateway exchange key, bound to the gateway node ID and key version.
+func Seal(gatewayKey *PublicKey, plaintext []byte, nodeID string, keyVersion uint32) (*Envelope, error) {
+	ephemeral, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(ephemeral, gatewayKey, ephemeral.Public(), gatewayKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, associatedData(nodeID, keyVersion))
+	return &Envelope{
+		EphemeralPublicKey: ephemeral.Public().Encode(),
+		Nonce:              hex.EncodeToString(nonce),
+		Ciphertext:         hex.EncodeToString(ciphertext),
+	}, nil
+}
+
+// Open decrypts an envelope with the gateway exchange key. It fails if the envelope
+// has been modified or was sealed for a different node ID or key version.
+func Open(gatewayKey *PrivateKey, env *Envelope, nodeID string, keyVersion uint32) ([]byte, error) {
+	ephemeralPub, err := DecodePublicKey(env.EphemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(env.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := hex.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(gatewayKey, ephemeralPub, ephemeralPub, gatewayKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("Envelope nonce has the wrong length")
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, associatedData(nodeID, keyVersion))
+	if err != nil {
+		return nil, errors.New("Unable to decrypt key envelope")
+	}
+	return plaintext, nil
+}
+
+// newAEAD derives the envelope key from the X25519 shared secret. The salt binds both
+// public keys so the derived key is unique to this exchange.
+func newAEAD(own *PrivateKey, peer *PublicKey, ephemeralPub *PublicKey, gatewayPub *PublicKey) (cipher.AEAD, error) {
+	shared, err := curve25519.X25519(own.private[:], peer[:])
+	if err != nil {
+		return nil, err
+	}
+	salt := append(append([]byte{}, ephemeralPub[:]...), gatewayPub[:]...)
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(hkdfInfo)), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+func associatedData(nodeID string, keyVersion uint32) []byte {
+	ver := make([]byte, 4)
+	binary.BigEndian.PutUint32(ver, keyVersion)
+	return append([]byte(nodeID), ver...)
+}
@@ -0,0 +1,139 @@
+package fcrkeyexchange
+
+// Copyright (C) 2020 ConsenSys Software Inc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+const (
+	testNodeID     = "0102030405"
+	testKeyVersion = uint32(2)
+)
+
+var testPlaintext = []byte("gateway private key")
+
+// flipHex flips the low bit of the first byte of a hex encoded value.
+func flipHex(t *testing.T, encoded string) string {
+	t.Helper()
+	raw, err := hex.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[0] ^= 1
+	return hex.EncodeToString(raw)
+}
+
+func TestSealOpen(t *testing.T) {
+	gatewayKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := gatewayKey.Public().Encode()
+	decoded, err := DecodePublicKey(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *decoded != *gatewayKey.Public() {
+		t.Fatal("decoded public key differs from the encoded key")
+	}
+
+	tests := []struct {
+		name       string
+		key        *PrivateKey
+		nodeID     string
+		keyVersion uint32
+		tamper     func(env *Envelope)
+		wantErr    bool
+	}{
+		{"unchanged", gatewayKey, testNodeID, testKeyVersion, func(*Envelope) {}, false},
+		{"other gateway key", otherKey, testNodeID, testKeyVersion, func(*Envelope) {}, true},
+		{"other node ID", gatewayKey, "0102030406", testKeyVersion, func(*Envelope) {}, true},
+		{"other key version", gatewayKey, testNodeID, testKeyVersion + 1, func(*Envelope) {}, true},
+		{"ciphertext modified", gatewayKey, testNodeID, testKeyVersion, func(env *Envelope) {
+			env.Ciphertext = flipHex(t, env.Ciphertext)
+		}, true},
+		{"ciphertext truncated", gatewayKey, testNodeID, testKeyVersion, func(env *Envelope) {
+			env.Ciphertext = env.Ciphertext[:len(env.Ciphertext)-2]
+		}, true},
+		{"nonce modified", gatewayKey, testNodeID, testKeyVersion, func(env *Envelope) {
+			env.Nonce = flipHex(t, env.Nonce)
+		}, true},
+		{"nonce wrong length", gatewayKey, testNodeID, testKeyVersion, func(env *Envelope) {
+			env.Nonce = env.Nonce[:len(env.Nonce)-2]
+		}, true},
+		{"ephemeral key modified", gatewayKey, testNodeID, testKeyVersion, func(env *Envelope) {
+			env.EphemeralPublicKey = flipHex(t, env.EphemeralPublicKey)
+		}, true},
+		{"ephemeral key not hex", gatewayKey, testNodeID, testKeyVersion, func(env *Envelope) {
+			env.EphemeralPublicKey = "zz"
+		}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			env, err := Seal(decoded, testPlaintext, testNodeID, testKeyVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.tamper(env)
+			plaintext, err := Open(test.key, env, test.nodeID, test.keyVersion)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("opened an envelope which should have been rejected: %q", plaintext)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(plaintext, testPlaintext) {
+				t.Errorf("opened %q, want %q", plaintext, testPlaintext)
+			}
+		})
+	}
+}
+
+func TestSealUsesFreshKeys(t *testing.T) {
+	gatewayKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := Seal(gatewayKey.Public(), testPlaintext, testNodeID, testKeyVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Seal(gatewayKey.Public(), testPlaintext, testNodeID, testKeyVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.EphemeralPublicKey == second.EphemeralPublicKey || first.Ciphertext == second.Ciphertext {
+		t.Error("two envelopes of the same key share an ephemeral key or ciphertext")
+	}
+}
+
+func TestDecodePublicKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr bool
+	}{
+		{"valid", hex.EncodeToString(make([]byte, 32)), false},
+		{"too short", hex.EncodeToString(make([]byte, 31)), true},
+		{"too long", hex.EncodeToString(make([]byte, 33)), true},
+		{"not hex", "not hex", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodePublicKey(test.encoded)
+			if (err != nil) != test.wantErr {
+				t.Errorf("DecodePublicKey(%q) returned %v, want error %t", test.encoded, err, test.wantErr)
+			}
+		})
+	}
+}
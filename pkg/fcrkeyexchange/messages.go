@@ -0,0 +1,111 @@
+package fcrkeyexchange
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"fmt"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+
+	"github.com/ConsenSys/fc-retrieval-gateway-admin/internal/wire"
+)
+
+// Message types. These follow on from the admin message types in fcrmessages.
+// The response to AdminAcceptEncryptedKeyChallenge is fcrmessages.AdminAcceptKeyResponseType.
+const (
+	AdminGetExchangeKeyChallengeType     = 206
+	AdminGetExchangeKeyResponseType      = 207
+	AdminAcceptEncryptedKeyChallengeType = 208
+)
+
+// AdminGetExchangeKeyChallenge is the request from an admin client to a gateway for an exchange key.
+type AdminGetExchangeKeyChallenge struct {
+	NodeID nodeid.NodeID `json:"node_id"`
+}
+
+// AdminGetExchangeKeyResponse is the response to AdminGetExchangeKeyChallenge
+type AdminGetExchangeKeyResponse struct {
+	ExchangeKey string `json:"exchange_key"`
+}
+
+// AdminAcceptEncryptedKeyChallenge is the request from an admin client to a gateway to
+// install a private key which has been sealed to the gateway's exchange key.
+type AdminAcceptEncryptedKeyChallenge struct {
+	NodeID            nodeid.NodeID `json:"node_id"`
+	EncryptedKey      Envelope      `json:"encrypted_key"`
+	PrivateKeyVersion uint32        `json:"privatekeyversion"`
+}
+
+// EncodeAdminGetExchangeKeyChallenge is used to get the FCRMessage of AdminGetExchangeKeyChallenge
+func EncodeAdminGetExchangeKeyChallenge(nodeID *nodeid.NodeID) (*fcrmessages.FCRMessage, error) {
+	return wire.Encode(AdminGetExchangeKeyChallengeType, AdminGetExchangeKeyChallenge{*nodeID})
+}
+
+// DecodeAdminGetExchangeKeyChallenge is used to get the fields from FCRMessage of AdminGetExchangeKeyChallenge
+func DecodeAdminGetExchangeKeyChallenge(fcrMsg *fcrmessages.FCRMessage) (*nodeid.NodeID, error) {
+	msg := AdminGetExchangeKeyChallenge{}
+	if err := wire.Decode(fcrMsg, AdminGetExchangeKeyChallengeType, &msg); err != nil {
+		return nil, err
+	}
+	return &msg.NodeID, nil
+}
+
+// EncodeAdminGetExchangeKeyResponse is used to get the FCRMessage of AdminGetExchangeKeyResponse
+func EncodeAdminGetExchangeKeyResponse(exchangeKey *PublicKey) (*fcrmessages.FCRMessage, error) {
+	return wire.Encode(AdminGetExchangeKeyResponseType, AdminGetExchangeKeyResponse{exchangeKey.Encode()})
+}
+
+// DecodeAdminGetExchangeKeyResponse is used to get the fields from FCRMessage of AdminGetExchangeKeyResponse
+func DecodeAdminGetExchangeKeyResponse(fcrMsg *fcrmessages.FCRMessage) (*PublicKey, error) {
+	msg := AdminGetExchangeKeyResponse{}
+	if err := wire.Decode(fcrMsg, AdminGetExchangeKeyResponseType, &msg); err != nil {
+		return nil, err
+	}
+	return DecodePublicKey(msg.ExchangeKey)
+}
+
+// EncodeAdminAcceptEncryptedKeyChallenge seals the private key to the gateway exchange key and
+// returns the FCRMessage of AdminAcceptEncryptedKeyChallenge
+func EncodeAdminAcceptEncryptedKeyChallenge(
+	nodeID *nodeid.NodeID,
+	privateKey string, // privatekey encoded as a hex string
+	keyVersion uint32,
+	exchangeKey *PublicKey,
+) (*fcrmessages.FCRMessage, error) {
+	env, err := Seal(exchangeKey, []byte(privateKey), nodeID.ToString(), keyVersion)
+	if err != nil {
+		return nil, err
+	}
+	return wire.Encode(AdminAcceptEncryptedKeyChallengeType, AdminAcceptEncryptedKeyChallenge{*nodeID, *env, keyVersion})
+}
+
+// DecodeAdminAcceptEncryptedKeyChallenge decrypts the private key in an AdminAcceptEncryptedKeyChallenge
+// using the gateway exchange key. It returns the same fields as fcrmessages.DecodeAdminAcceptKeyChallenge.
+func DecodeAdminAcceptEncryptedKeyChallenge(fcrMsg *fcrmessages.FCRMessage, exchangeKey *PrivateKey) (*nodeid.NodeID, string, uint32, error) {
+	msg := AdminAcceptEncryptedKeyChallenge{}
+	if err := wire.Decode(fcrMsg, AdminAcceptEncryptedKeyChallengeType, &msg); err != nil {
+		return nil, "", 0, err
+	}
+	privateKey, err := Open(exchangeKey, &msg.EncryptedKey, msg.NodeID.ToString(), msg.PrivateKeyVersion)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if len(privateKey) == 0 {
+		return nil, "", 0, fmt.Errorf("New Gateway Private Key empty")
+	}
+	return &msg.NodeID, string(privateKey), msg.PrivateKeyVersion, nil
+}